@@ -0,0 +1,122 @@
+package booktools
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Address locates a chunk within a work by its position, counting from
+// 1, among the chapters, sections within the chapter, paragraphs within
+// the section and sentences within the paragraph. Levels below the
+// chunk's own unit are 0, so "3.2.5" is the fifth paragraph of the
+// second section of chapter three.
+type Address struct {
+	Chapter   int
+	Section   int
+	Paragraph int
+	Sentence  int
+}
+
+func (a Address) String() string {
+	parts := []int{a.Chapter, a.Section, a.Paragraph, a.Sentence}
+	for len(parts) > 1 && parts[len(parts)-1] == 0 {
+		parts = parts[0 : len(parts)-1]
+	}
+	s := make([]string, len(parts))
+	for i, p := range parts {
+		s[i] = strconv.Itoa(p)
+	}
+	return strings.Join(s, ".")
+}
+
+// ParseAddress parses an address such as "3.2.5".
+func ParseAddress(s string) (Address, error) {
+	var a Address
+	parts := strings.Split(s, ".")
+	if len(parts) > 4 {
+		return a, fmt.Errorf("address %v has too many parts", s)
+	}
+	fields := []*int{&a.Chapter, &a.Section, &a.Paragraph, &a.Sentence}
+	for i, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil || n < 1 {
+			return a, fmt.Errorf("could not parse address %v", s)
+		}
+		*fields[i] = n
+	}
+	return a, nil
+}
+
+// Lookup returns the chunk at a beneath the work c, or nil if there is
+// no such chunk.
+func (c *Chunk) Lookup(a Address) *Chunk {
+	cur := c
+	for _, i := range []int{a.Chapter, a.Section, a.Paragraph, a.Sentence} {
+		if i == 0 {
+			break
+		}
+		if i > len(cur.Children) {
+			return nil
+		}
+		cur = cur.Children[i-1]
+	}
+	return cur
+}
+
+// Walk calls fn for every chunk of the given unit beneath the work c,
+// along with its address, stopping early if fn returns false. Walking
+// Words gives each word the address of its sentence.
+func (c *Chunk) Walk(unit int, fn func(a Address, c *Chunk) bool) {
+	var a Address
+	levels := []*int{&a.Chapter, &a.Section, &a.Paragraph, &a.Sentence}
+	var walk func(chunk *Chunk, depth int) bool
+	walk = func(chunk *Chunk, depth int) bool {
+		for i, child := range chunk.Children {
+			if depth < len(levels) {
+				*levels[depth] = i + 1
+			}
+			if child.Unit == unit {
+				if !fn(a, child) {
+					return false
+				}
+				continue
+			}
+			if child.Unit > unit && !walk(child, depth+1) {
+				return false
+			}
+		}
+		if depth < len(levels) {
+			*levels[depth] = 0
+		}
+		return true
+	}
+	walk(c, 0)
+}
+
+// MarshalText writes the address in its dotted form.
+func (a Address) MarshalText() ([]byte, error) {
+	return []byte(a.String()), nil
+}
+
+// UnmarshalText reads an address in its dotted form.
+func (a *Address) UnmarshalText(b []byte) error {
+	parsed, err := ParseAddress(string(b))
+	if err != nil {
+		return err
+	}
+	*a = parsed
+	return nil
+}
+
+// Before reports whether a comes before b in reading order.
+func (a Address) Before(b Address) bool {
+	x := []int{a.Chapter, a.Section, a.Paragraph, a.Sentence}
+	y := []int{b.Chapter, b.Section, b.Paragraph, b.Sentence}
+	for i := range x {
+		if x[i] != y[i] {
+			return x[i] < y[i]
+		}
+	}
+	return false
+}
@@ -0,0 +1,51 @@
+package booktools
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Result is the typed output of an Analyzer. Every Result can be laid
+// out as a table, one row per value, or printed as plain text.
+type Result interface {
+	Columns() []string
+	Rows() [][]string
+	String() string
+}
+
+// Analyzer is a named analysis of a Chunk. Unit is the unit each row
+// of the Result describes, e.g. Chapter for a per-chapter report.
+type Analyzer interface {
+	Name() string
+	Description() string
+	Unit() int
+	Analyze(c *Chunk) Result
+}
+
+var analyzers = make(map[string]Analyzer)
+
+// RegisterAnalyzer makes an Analyzer available by name. Registered
+// analyzers are offered as process subcommands and by the server.
+func RegisterAnalyzer(a Analyzer) {
+	if _, ok := analyzers[a.Name()]; ok {
+		panic(fmt.Sprintf("booktools: analyzer %v registered twice", a.Name()))
+	}
+	analyzers[a.Name()] = a
+}
+
+// GetAnalyzer returns the registered Analyzer called name, or nil.
+func GetAnalyzer(name string) Analyzer {
+	return analyzers[name]
+}
+
+// Analyzers returns every registered Analyzer, sorted by name.
+func Analyzers() []Analyzer {
+	list := make([]Analyzer, 0, len(analyzers))
+	for _, a := range analyzers {
+		list = append(list, a)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Name() < list[j].Name()
+	})
+	return list
+}
@@ -0,0 +1,59 @@
+// Copyright © 2018 Howard C. Shaw III <howardcshaw@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"log"
+	"os"
+
+	bt "github.com/TheGrum/booktools"
+
+	"github.com/spf13/cobra"
+)
+
+// addAnalyzerCommands adds a process subcommand for every registered
+// analyzer which does not already have a command of its own.
+func addAnalyzerCommands() {
+	existing := make(map[string]bool)
+	for _, c := range processCmd.Commands() {
+		existing[c.Name()] = true
+	}
+	for _, a := range bt.Analyzers() {
+		if existing[a.Name()] {
+			continue
+		}
+		analyzer := a
+		processCmd.AddCommand(&cobra.Command{
+			Use:   analyzer.Name(),
+			Short: analyzer.Description(),
+			Run: func(cmd *cobra.Command, args []string) {
+				printResult(analyzer.Analyze(processRoot))
+			},
+		})
+	}
+}
+
+// printResult writes r to standard output in the format selected with
+// --format. Results which are Diagnostics are placed against the lines
+// of the processed file for the sarif and checkstyle formats.
+func printResult(r bt.Result) {
+	if d, ok := r.(bt.Diagnostics); ok && bt.IsDiagnosticFormat(outputFormat) {
+		r = d.Diagnose(bt.NewSourceMapFiles(processSources, processText, processRoot))
+	}
+	err := bt.WriteResult(os.Stdout, r, outputFormat)
+	if err != nil {
+		log.Fatalf("Error writing output: %v", err)
+	}
+}
@@ -15,6 +15,8 @@
 package cmd
 
 import (
+	bt "github.com/TheGrum/booktools"
+
 	"github.com/spf13/cobra"
 )
 
@@ -24,7 +26,7 @@ var chapterCmd = &cobra.Command{
 	Short: "Displays the contents of a chapter",
 	Long:  `Displays the contents of a chapter`,
 	Run: func(cmd *cobra.Command, args []string) {
-		processRoot.PrintChapter(selectedChapter)
+		printResult(bt.ChapterText{Chapter: selectedChapter, Text: processRoot.GetChapter(selectedChapter)})
 	},
 }
 
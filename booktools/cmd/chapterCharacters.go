@@ -15,6 +15,8 @@
 package cmd
 
 import (
+	bt "github.com/TheGrum/booktools"
+
 	"github.com/spf13/cobra"
 )
 
@@ -24,7 +26,15 @@ var chapterCharactersCmd = &cobra.Command{
 	Short: "Lists the characters in each chapter",
 	Long:  `Lists the top appearing characters per chapter`,
 	Run: func(cmd *cobra.Command, args []string) {
-		processRoot.PrintTopXCharactersPerChapter(includeSentences, includeXthSentence, topX, tabDelimit, wordCount)
+		if tabDelimit {
+			outputFormat = "tsv"
+		}
+		printResult(bt.ChapterCharacterAnalyzer{
+			TopX:               topX,
+			IncludeSentences:   includeSentences,
+			IncludeXthSentence: includeXthSentence,
+			WordCount:          wordCount,
+		}.Analyze(processRoot))
 	},
 }
 
@@ -47,6 +57,7 @@ func init() {
 	// chapterCharactersCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
 	chapterCharactersCmd.Flags().BoolVarP(&includeSentences, "includeSentences", "s", false, "Include first sentence of each chapter")
 	chapterCharactersCmd.Flags().BoolVarP(&tabDelimit, "tabDelimit", "b", false, "Tab delimit the output")
+	chapterCharactersCmd.Flags().MarkDeprecated("tabDelimit", "use --format tsv instead")
 	chapterCharactersCmd.Flags().BoolVarP(&wordCount, "wordCount", "w", false, "Include word count of each chapter")
 	chapterCharactersCmd.Flags().IntVarP(&includeXthSentence, "includeXth", "x", -1, "Include Xth sentence of each chapter")
 	chapterCharactersCmd.Flags().IntVarP(&topX, "topX", "t", 3, "Show top X characters")
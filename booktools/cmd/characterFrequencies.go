@@ -25,7 +25,7 @@ var characterFrequenciesCmd = &cobra.Command{
 	Use:   "characterFrequencies",
 	Short: "Lists the characters and the frequency with which they appear.",
 	Run: func(cmd *cobra.Command, args []string) {
-		bt.PrintNameFrequency(bt.CharacterFrequencies(processRoot, 3, 1))
+		printResult(bt.CharacterFrequencyAnalyzer{MinAppearance: 3, MinNonFirst: 1}.Analyze(processRoot))
 	},
 }
 
@@ -24,15 +24,26 @@ import (
 var charactersCmd = &cobra.Command{
 	Use:   "characters",
 	Short: "Lists the characters in the book",
-	Long:  `Lists the characters in the book.`,
+	Long: `Lists the characters in the book.
+
+With --aliases, lists instead the names spelled more than one way,
+suggesting the less used spellings as aliases of the most used.`,
 	Run: func(cmd *cobra.Command, args []string) {
-		bt.SortAndPrintLines(bt.IdentifyCharacters(processRoot, 3, 1))
+		if characterAliases {
+			printResult(bt.CharacterAliasAnalyzer{MinLength: 4}.Analyze(processRoot))
+			return
+		}
+		printResult(bt.CharacterAnalyzer{MinAppearance: 3, MinNonFirst: 1}.Analyze(processRoot))
 	},
 }
 
+var characterAliases bool
+
 func init() {
 	processCmd.AddCommand(charactersCmd)
 
+	charactersCmd.Flags().BoolVarP(&characterAliases, "aliases", "a", false, "Suggest aliases for names spelled more than one way")
+
 	// Here you will define your flags and configuration settings.
 
 	// Cobra supports Persistent Flags which will work for this command
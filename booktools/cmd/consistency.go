@@ -0,0 +1,41 @@
+// Copyright © 2018 Howard C. Shaw III <howardcshaw@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	bt "github.com/TheGrum/booktools"
+
+	"github.com/spf13/cobra"
+)
+
+// consistencyCmd represents the consistency command
+var consistencyCmd = &cobra.Command{
+	Use:   "consistency",
+	Short: "Flags paragraphs whose tense or point of view differs from their section",
+	Long: `Estimates the dominant tense (past or present) and narrative person
+(first, second or third) of each section from the verbs and
+pronouns outside dialogue, and flags the paragraphs which differ.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		printResult(bt.ConsistencyAnalyzer{MinEvidence: consistencyMinEvidence}.Analyze(processRoot))
+	},
+}
+
+var consistencyMinEvidence int
+
+func init() {
+	processCmd.AddCommand(consistencyCmd)
+
+	consistencyCmd.Flags().IntVarP(&consistencyMinEvidence, "minEvidence", "m", 3, "Ignore paragraphs with fewer tense or pronoun markers than this")
+}
@@ -0,0 +1,73 @@
+// Copyright © 2018 Howard C. Shaw III <howardcshaw@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"log"
+	"os"
+
+	bt "github.com/TheGrum/booktools"
+
+	"github.com/spf13/cobra"
+)
+
+// diffCmd represents the diff command
+var diffCmd = &cobra.Command{
+	Use:   "diff old new",
+	Short: "Shows what changed between two drafts of a manuscript",
+	Long: `Compares two drafts of a manuscript: the chapters added, removed,
+moved or changed, with the change in each one's word count, the
+sections moved, the paragraphs added, removed, rewritten or moved, and
+the change in how often each character is named.
+
+Paragraphs are paired by their text where it is unchanged, and
+otherwise with the most similar paragraph nearby, so long as they share
+--minSimilarity of their words.
+
+With --format html, the two drafts are written side by side, with
+removed and inserted words marked.`,
+	Args: cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		useProject(cmd, args[1:])
+		drafts := make([]*bt.Chunk, len(args))
+		for i, arg := range args {
+			file, err := os.Open(arg)
+			if err != nil {
+				log.Fatalf("Error opening file to compare: %v", err)
+			}
+			drafts[i] = Process(file)
+			file.Close()
+		}
+		d := bt.Diff(drafts[0], drafts[1], diffMinSimilarity)
+		if outputFormat == "html" {
+			err := bt.WriteDiffHTML(os.Stdout, d)
+			if err != nil {
+				log.Fatalf("Error writing comparison: %v", err)
+			}
+			return
+		}
+		printResult(d)
+	},
+}
+
+var diffMinSimilarity float64
+
+func init() {
+	rootCmd.AddCommand(diffCmd)
+
+	diffCmd.Flags().StringVarP(&outputFormat, "format", "f", "text", "Output format, one of text, json, csv, tsv, yaml, html")
+	diffCmd.Flags().StringVarP(&chapterRegex, "chapterRegex", "r", "", "Regular expression which if matched on a line will trigger a chapter.")
+	diffCmd.Flags().Float64Var(&diffMinSimilarity, "minSimilarity", 0.5, "Share of their words a rewritten paragraph must keep to be paired with the original")
+}
@@ -15,8 +15,6 @@
 package cmd
 
 import (
-	"fmt"
-
 	"github.com/spf13/cobra"
 )
 
@@ -25,7 +23,7 @@ var displayCmd = &cobra.Command{
 	Use:   "display",
 	Short: "Displays the processed structure",
 	Run: func(cmd *cobra.Command, args []string) {
-		fmt.Printf("%v", processRoot.PrintStructure(includeSentences, maxDepth))
+		printResult(processRoot.Structure(includeSentences, maxDepth))
 	},
 }
 
@@ -0,0 +1,43 @@
+// Copyright © 2018 Howard C. Shaw III <howardcshaw@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	bt "github.com/TheGrum/booktools"
+
+	"github.com/spf13/cobra"
+)
+
+// echoesCmd represents the echoes command
+var echoesCmd = &cobra.Command{
+	Use:   "echoes",
+	Short: "Finds words repeated close together and repeated sentence openers",
+	Long: `Finds words repeated within a few words of each other, such as
+"He looked at the door. The door looked old", and consecutive
+sentences which open with the same word.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		printResult(echoAnalyzer.Analyze(processRoot))
+	},
+}
+
+var echoAnalyzer bt.EchoAnalyzer
+
+func init() {
+	processCmd.AddCommand(echoesCmd)
+
+	echoesCmd.Flags().IntVarP(&echoAnalyzer.Window, "window", "w", 30, "Flag words repeated within this many words")
+	echoesCmd.Flags().IntVarP(&echoAnalyzer.MinLength, "minLength", "m", 4, "Ignore words shorter than this")
+	echoesCmd.Flags().BoolVar(&echoAnalyzer.Stem, "stem", true, "Treat other forms of a word as repeats")
+}
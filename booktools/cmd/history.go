@@ -0,0 +1,52 @@
+// Copyright © 2018 Howard C. Shaw III <howardcshaw@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"log"
+
+	bt "github.com/TheGrum/booktools"
+
+	"github.com/spf13/cobra"
+)
+
+// historyCmd represents the history command
+var historyCmd = &cobra.Command{
+	Use:   "history file",
+	Short: "Charts a manuscript's word counts through its git history",
+	Long: `Reads each revision of a manuscript committed to the git repository
+it is in, following it through renames, and lists the total and
+per-chapter word counts, chapter count and character count as of each
+commit, oldest first. Use --format csv for a spreadsheet.
+
+The git command must be installed.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		useProject(cmd, args)
+		h := bt.NewHistory(args[0], chapterStart())
+		err := h.Update()
+		if err != nil {
+			log.Fatalf("Error reading history: %v", err)
+		}
+		printResult(h)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(historyCmd)
+
+	historyCmd.Flags().StringVarP(&outputFormat, "format", "f", "text", "Output format, one of text, json, csv, tsv, yaml")
+	historyCmd.Flags().StringVarP(&chapterRegex, "chapterRegex", "r", "", "Regular expression which if matched on a line will trigger a chapter.")
+}
@@ -0,0 +1,213 @@
+// Copyright © 2018 Howard C. Shaw III <howardcshaw@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	bt "github.com/TheGrum/booktools"
+
+	"github.com/spf13/cobra"
+)
+
+// initCmd represents the init command
+var initCmd = &cobra.Command{
+	Use:   "init [file...]",
+	Short: "Writes a booktools.yaml project file for a manuscript",
+	Long: `Writes booktools.yaml, the project file holding the settings every
+booktools command uses for a manuscript, into the directory of its
+first file. With no files, the .txt and .md files of the current
+directory are taken, in order of their names.
+
+The manuscript is read to fill in what it can: the files and their
+order, a chapterRegex where the built-in rules find fewer than two
+chapters, and the other spellings of characters' names as aliases.
+The other settings are written as comments to be filled in.
+
+An existing project file is only replaced with --force.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		files := args
+		dir := "."
+		if len(files) == 0 {
+			files = manuscriptFiles(dir)
+		} else {
+			dir = filepath.Dir(files[0])
+		}
+		if len(files) == 0 {
+			log.Fatalf("No manuscript found: name its files, or run init in the directory holding its .txt or .md files")
+		}
+		path := filepath.Join(dir, bt.ProjectFile)
+		if _, err := os.Stat(path); err == nil && !initForce {
+			log.Fatalf("%v already exists; use --force to replace it", path)
+		}
+		processFiles = files
+		root := load(files)
+		regex := ""
+		if len(root.Chapters()) < 2 {
+			regex, root = guessChapterRegex(root)
+		}
+		names := make([]string, len(files))
+		for i, f := range files {
+			rel, err := filepath.Rel(dir, f)
+			if err != nil {
+				rel = f
+			}
+			names[i] = filepath.ToSlash(rel)
+		}
+		err := ioutil.WriteFile(path, []byte(projectTemplate(names, regex, root)), 0644)
+		if err != nil {
+			log.Fatalf("Error writing project file: %v", err)
+		}
+		fmt.Printf("Wrote %v: %d files, %d words in %d chapters\n", path, len(files), root.GetWordCount(), len(root.Chapters()))
+	},
+}
+
+var initForce bool
+
+func init() {
+	rootCmd.AddCommand(initCmd)
+
+	initCmd.Flags().BoolVar(&initForce, "force", false, "Replace an existing project file")
+}
+
+// manuscriptFiles lists the .txt and .md files of dir, by name.
+func manuscriptFiles(dir string) []string {
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		log.Fatalf("Error reading directory: %v", err)
+	}
+	files := make([]string, 0)
+	for _, e := range entries {
+		ext := strings.ToLower(filepath.Ext(e.Name()))
+		if !e.IsDir() && (ext == ".txt" || ext == ".md") {
+			files = append(files, filepath.Join(dir, e.Name()))
+		}
+	}
+	sort.Strings(files)
+	return files
+}
+
+// chapterRegexes are the usual ways of marking chapters, tried in turn
+// where the built-in rules find too few.
+var chapterRegexes = []string{
+	`(?i)^\s*chapter\b`,
+	`^\s*#{1,2} `,
+	`^\s*(\d+|[IVXLC]+)\.?\s*$`,
+	`(?i)^\s*part\b`,
+}
+
+// guessChapterRegex returns the first of chapterRegexes which finds at
+// least two chapters in the text read into root, and the text chunked
+// by it, or "" and root where none does.
+func guessChapterRegex(root *bt.Chunk) (string, *bt.Chunk) {
+	for _, r := range chapterRegexes {
+		reg := regexp.MustCompile(r)
+		guess := bt.Parse(processText, func(s string) bool {
+			return reg.MatchString(s)
+		})
+		if len(guess.Chapters()) >= 2 {
+			return r, guess
+		}
+	}
+	return "", root
+}
+
+// projectTemplate writes a project file for the files of the work root,
+// with the settings which could not be found out commented.
+func projectTemplate(files []string, chapterRegex string, root *bt.Chunk) string {
+	sb := strings.Builder{}
+	sb.WriteString(`# Settings for booktools, used by every command run on the manuscript
+# below. Flags given on the command line take precedence. Paths are
+# relative to this file.
+
+# The manuscript's files, read in this order as a single work.
+files:
+`)
+	for _, f := range files {
+		sb.WriteString(fmt.Sprintf("  - %v\n", strconv.Quote(f)))
+	}
+
+	sb.WriteString(`
+# The output format where --format is not given.
+# format: text
+
+# Matches the sentences which start chapters, in place of the built-in
+# rules.
+`)
+	if chapterRegex != "" {
+		sb.WriteString(fmt.Sprintf("chapterRegex: %v\n", strconv.Quote(chapterRegex)))
+	} else {
+		sb.WriteString("# chapterRegex: \"^Chapter \"\n")
+	}
+
+	sb.WriteString(`
+# Aliases are the other names a character goes by, counted under the
+# name they map to. Ignore lists capitalized words which are not
+# characters.
+`)
+	aliases := bt.SuggestAliases(root, 4)
+	if len(aliases) > 0 {
+		sb.WriteString("characters:\n  aliases:\n")
+		for _, a := range aliases {
+			for _, alias := range a.Aliases {
+				sb.WriteString(fmt.Sprintf("    %v: %v\n", strconv.Quote(alias), strconv.Quote(a.Name)))
+			}
+		}
+		sb.WriteString("  # ignore: [Monday, God]\n")
+	} else {
+		sb.WriteString(`# characters:
+#   aliases:
+#     "Bob": "Robert"
+#   ignore: [Monday, God]
+`)
+	}
+
+	sb.WriteString(fmt.Sprintf(`
+# Words to leave out, besides the commonest, when looking for echoes and
+# overused words.
+# stopWords: [just, really]
+
+# The lint rules: packs of rules built into booktools, files of further
+# rules, and the IDs of rules to switch on or off.
+# lint:
+#   packs: [%v]
+#   rules: [house.yaml]
+#   disable: [TYPO007]
+
+# Word-count targets for the progress command. The manuscript has %d
+# words.
+# targets:
+#   words: 90000
+#   deadline: 2027-03-01
+#   chapterWords: 3000
+#   chapters:
+#     1: 4500
+
+# Defaults for serve.
+# server:
+#   port: 8080
+#   theme: theme
+#   watch: true
+`, strings.Join(bt.RulePacks(), ", "), root.GetWordCount()))
+	return sb.String()
+}
@@ -0,0 +1,212 @@
+// Copyright © 2018 Howard C. Shaw III <howardcshaw@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	bt "github.com/TheGrum/booktools"
+
+	"github.com/spf13/cobra"
+)
+
+// lintCmd represents the lint command
+var lintCmd = &cobra.Command{
+	Use:   "lint [file...]",
+	Short: "Checks the typography and punctuation of a manuscript",
+	Long: `Checks the typography and punctuation of a manuscript: double spaces,
+mixed straight and curly quotation marks, unmatched quotation marks,
+hyphens used as dashes, inconsistent ellipses, spaces before
+punctuation and paragraphs missing their closing punctuation.
+
+With no files, those listed in the project file are checked, or
+otherwise standard input. Each finding is listed with its line and
+column in its file. With --fix, a corrected copy is written to the
+file named by --output, or beside each input with .fixed before its
+extension, and the findings which could not be fixed are listed.
+
+Further rules are read from YAML files given with --rules, or from
+the rule packs built into booktools given with --pack. A rule is
+declared with an id, a pattern (a regular expression) or tokens (words
+where * matches any word), the scope it matches within (line, sentence
+or paragraph), a severity (error, warning or info), a message and an
+optional suggested replacement:
+
+  rules:
+    - id: HOUSE001
+      tokens: alright
+      scope: sentence
+      severity: warning
+      message: Use "all right" rather than "$0"
+      suggestion: all right
+  disable: [TYPO007]
+
+Rules may be switched on and off with --enable and --disable, and in
+the manuscript with comments:
+
+  <!-- booktools-disable TYPO001 -->  until enabled again
+  <!-- booktools-enable TYPO001 -->
+  <!-- booktools-disable-line -->      for every rule on this line
+  <!-- booktools-disable-next-line HOUSE001 -->
+
+With --maxErrors, lint exits with status 1 when there are more than
+that many findings of the --failOn severity or worse.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		useProject(cmd, args)
+		rules, err := lintRules()
+		if err != nil {
+			log.Fatalf("Error loading rules: %v", err)
+		}
+		if lintListRules {
+			printResult(rules.List())
+			return
+		}
+		names := args
+		if len(names) == 0 {
+			names = project.Paths()
+		}
+		if len(names) == 0 {
+			names = []string{"-"}
+		}
+		if lintFix && lintOutput != "" && len(names) > 1 {
+			log.Fatalf("--output names one corrected copy, but there are %d files", len(names))
+		}
+		if lintFix && lintOutput == "" && names[0] == "-" {
+			// The corrected copy is written to standard output, in
+			// place of the findings.
+			lintFile(rules, "-")
+			return
+		}
+		findings := make(bt.LintFindings, 0)
+		for _, name := range names {
+			findings = append(findings, lintFile(rules, name)...)
+		}
+		printResult(findings)
+		if n := findings.Count(lintFailOn); lintMaxErrors >= 0 && n > lintMaxErrors {
+			fmt.Fprintf(os.Stderr, "%d findings of %v or worse, more than the %d allowed\n", n, lintFailOn, lintMaxErrors)
+			os.Exit(1)
+		}
+	},
+}
+
+var lintFix bool
+var lintOutput string
+var lintRuleFiles []string
+var lintPacks []string
+var lintEnable []string
+var lintDisable []string
+var lintListRules bool
+var lintMaxErrors int
+var lintFailOn string
+
+func init() {
+	rootCmd.AddCommand(lintCmd)
+
+	lintCmd.Flags().StringVarP(&outputFormat, "format", "f", "text", "Output format, one of "+strings.Join(bt.Formats, ", "))
+	lintCmd.Flags().BoolVar(&lintFix, "fix", false, "Write a copy of the input with the fixable findings corrected")
+	lintCmd.Flags().StringVarP(&lintOutput, "output", "o", "", "File to write the corrected copy to")
+	lintCmd.Flags().StringSliceVar(&lintRuleFiles, "rules", nil, "YAML file of further rules to check")
+	lintCmd.Flags().StringSliceVar(&lintPacks, "pack", nil, "Built-in rule pack to check, one of "+strings.Join(bt.RulePacks(), ", "))
+	lintCmd.Flags().StringSliceVar(&lintEnable, "enable", nil, "IDs of rules to switch on")
+	lintCmd.Flags().StringSliceVar(&lintDisable, "disable", nil, "IDs of rules to switch off")
+	lintCmd.Flags().BoolVar(&lintListRules, "listRules", false, "List the rules which would be checked instead of checking them")
+	lintCmd.Flags().IntVar(&lintMaxErrors, "maxErrors", -1, "Exit with status 1 when there are more than this many findings of the --failOn severity")
+	lintCmd.Flags().StringVar(&lintFailOn, "failOn", bt.SeverityError, "Least severity counted towards --maxErrors, one of error, warning, info")
+}
+
+// lintFile lints the named file, or standard input for "-", writing
+// the corrected copy with --fix and linting that instead.
+func lintFile(rules *bt.RuleSet, name string) bt.LintFindings {
+	var data []byte
+	var err error
+	if name == "-" {
+		data, err = ioutil.ReadAll(os.Stdin)
+	} else {
+		data, err = ioutil.ReadFile(name)
+	}
+	if err != nil {
+		log.Fatalf("Error reading file to lint: %v", err)
+	}
+	text := string(data)
+	findings := rules.Lint(text)
+	file := name
+	if name == "-" {
+		file = "stdin"
+	}
+	if lintFix {
+		fixed := bt.ApplyFixes(text, findings)
+		file, err = writeFixed(name, fixed)
+		if err != nil {
+			log.Fatalf("Error writing corrected copy: %v", err)
+		}
+		if file == "" {
+			return nil
+		}
+		findings = rules.Lint(fixed)
+	}
+	for i := range findings {
+		findings[i].File = file
+	}
+	return findings
+}
+
+// lintRules gathers the rule packs, rule files and switches given on
+// the command line.
+func lintRules() (*bt.RuleSet, error) {
+	rules := &bt.RuleSet{}
+	for _, name := range lintPacks {
+		pack, err := bt.RulePack(name)
+		if err != nil {
+			return nil, err
+		}
+		rules.Add(pack)
+	}
+	for _, name := range lintRuleFiles {
+		file, err := os.Open(name)
+		if err != nil {
+			return nil, err
+		}
+		set, err := bt.LoadRules(file)
+		file.Close()
+		if err != nil {
+			return nil, fmt.Errorf("%v: %v", name, err)
+		}
+		rules.Add(set)
+	}
+	rules.Enable = append(rules.Enable, lintEnable...)
+	rules.Disable = append(rules.Disable, lintDisable...)
+	return rules, nil
+}
+
+// writeFixed writes the corrected copy of the named input, returning
+// the name of the file written, or "" where the input came from
+// standard input and so the copy goes to standard output.
+func writeFixed(name string, fixed string) (string, error) {
+	out := lintOutput
+	if out == "" && name == "-" {
+		_, err := fmt.Print(fixed)
+		return "", err
+	}
+	if out == "" {
+		ext := filepath.Ext(name)
+		out = strings.TrimSuffix(name, ext) + ".fixed" + ext
+	}
+	return out, ioutil.WriteFile(out, []byte(fixed), 0644)
+}
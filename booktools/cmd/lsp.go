@@ -0,0 +1,61 @@
+// Copyright © 2018 Howard C. Shaw III <howardcshaw@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"log"
+	"os"
+
+	"github.com/TheGrum/booktools/booktools/lsp"
+	"github.com/spf13/cobra"
+)
+
+// lspCmd represents the lsp command
+var lspCmd = &cobra.Command{
+	Use:   "lsp",
+	Short: "Serves the Language Server Protocol over stdio",
+	Long: `Serves the Language Server Protocol over standard input and output,
+for editors to show the lint findings of a manuscript as it is opened
+and saved, the outline of its parts, chapters and sections, and the
+characters: hovering over a name shows how often it is used and where
+it first appears, going to its definition goes to that first mention,
+and workspace symbols search the characters of every open manuscript.
+
+The --rules, --pack, --enable and --disable flags choose the lint rules
+as they do for lint, and --chapterRegex starts chapters as it does for
+process.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		useProject(cmd, nil)
+		rules, err := lintRules()
+		if err != nil {
+			log.Fatalf("Error loading rules: %v", err)
+		}
+		err = lsp.Serve(os.Stdin, os.Stdout, rules, chapterStart())
+		if err != nil {
+			log.Fatalf("Error serving: %v", err)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(lspCmd)
+
+	lspCmd.Flags().StringSliceVar(&lintRuleFiles, "rules", nil, "YAML file of further rules to check")
+	lspCmd.Flags().StringSliceVar(&lintPacks, "pack", nil, "Built-in rule pack to check")
+	lspCmd.Flags().StringSliceVar(&lintEnable, "enable", nil, "IDs of rules to switch on")
+	lspCmd.Flags().StringSliceVar(&lintDisable, "disable", nil, "IDs of rules to switch off")
+	lspCmd.Flags().StringVarP(&chapterRegex, "chapterRegex", "r", "", "Regular expression which if matched on a line will trigger a chapter.")
+}
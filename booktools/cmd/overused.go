@@ -0,0 +1,117 @@
+// Copyright © 2018 Howard C. Shaw III <howardcshaw@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bufio"
+	"io"
+	"log"
+	"os"
+	"strings"
+
+	bt "github.com/TheGrum/booktools"
+
+	"github.com/spf13/cobra"
+)
+
+// overusedCmd represents the overused command
+var overusedCmd = &cobra.Command{
+	Use:   "overused",
+	Short: "Lists words used much more often than usual",
+	Long: `Lists words used significantly more often than in ordinary English,
+or than in a reference corpus given with --corpus (such as an
+earlier book), or a frequency list of "word count" lines given
+with --wordList. With a corpus, pairs of words are compared too.
+The built-in English list holds only the most common words, so
+others must be used twice as many times more often than usual as
+--minRatio asks; a --wordList of your own gives a truer picture.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		a := bt.OveruseAnalyzer{Baseline: bt.EnglishBaseline(), MinCount: overusedMinCount, MinRatio: overusedMinRatio}
+		var err error
+		switch {
+		case overusedCorpus != "":
+			a.Baseline, err = loadBaseline(overusedCorpus, bt.BaselineFromCorpus)
+		case overusedWordList != "":
+			a.Baseline, err = loadBaseline(overusedWordList, bt.BaselineFromList)
+		}
+		if err != nil {
+			log.Fatalf("Error reading baseline: %v", err)
+		}
+		printResult(a.Analyze(processRoot))
+	},
+}
+
+// crutchesCmd represents the crutches command
+var crutchesCmd = &cobra.Command{
+	Use:   "crutches",
+	Short: "Counts crutch words and phrases in each chapter",
+	Long: `Counts crutch words and phrases such as "just", "suddenly" and
+"began to" in each chapter, with their rate per 10,000 words.
+Replace the built-in list with --crutch or a file of one crutch
+per line with --crutchFile.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		crutches := bt.DefaultCrutches
+		if len(crutchList) > 0 {
+			crutches = crutchList
+		}
+		if crutchFile != "" {
+			file, err := os.Open(crutchFile)
+			if err != nil {
+				log.Fatalf("Error opening crutch file: %v", err)
+			}
+			defer file.Close()
+			crutches = make([]string, 0)
+			scanner := bufio.NewScanner(file)
+			for scanner.Scan() {
+				if s := strings.TrimSpace(scanner.Text()); s != "" && !strings.HasPrefix(s, "#") {
+					crutches = append(crutches, s)
+				}
+			}
+			if err := scanner.Err(); err != nil {
+				log.Fatalf("Error reading crutch file: %v", err)
+			}
+		}
+		printResult(bt.CrutchAnalyzer{Crutches: crutches}.Analyze(processRoot))
+	},
+}
+
+var overusedCorpus string
+var overusedWordList string
+var overusedMinCount int
+var overusedMinRatio float64
+var crutchList []string
+var crutchFile string
+
+func init() {
+	processCmd.AddCommand(overusedCmd)
+	processCmd.AddCommand(crutchesCmd)
+
+	overusedCmd.Flags().StringVar(&overusedCorpus, "corpus", "", "Text to compare against in place of ordinary English")
+	overusedCmd.Flags().StringVar(&overusedWordList, "wordList", "", "Frequency list to compare against in place of ordinary English")
+	overusedCmd.Flags().IntVarP(&overusedMinCount, "minCount", "m", 5, "Ignore words used fewer times than this")
+	overusedCmd.Flags().Float64VarP(&overusedMinRatio, "minRatio", "x", 3, "Ignore words used less than this many times as often as usual")
+
+	crutchesCmd.Flags().StringSliceVarP(&crutchList, "crutch", "c", nil, "Crutch words and phrases to count, separated by commas")
+	crutchesCmd.Flags().StringVar(&crutchFile, "crutchFile", "", "File of crutch words and phrases, one per line")
+}
+
+func loadBaseline(filename string, load func(r io.Reader) (*bt.Baseline, error)) (*bt.Baseline, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return load(file)
+}
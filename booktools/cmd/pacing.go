@@ -0,0 +1,43 @@
+// Copyright © 2018 Howard C. Shaw III <howardcshaw@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	bt "github.com/TheGrum/booktools"
+
+	"github.com/spf13/cobra"
+)
+
+// pacingCmd represents the pacing command
+var pacingCmd = &cobra.Command{
+	Use:   "pacing",
+	Short: "Reports sentence and paragraph lengths in each chapter",
+	Long: `Reports the distribution of sentence and paragraph lengths in
+each chapter, and flags walls of text and runs of sentences of
+the same length. Use --format csv to export the figures.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		printResult(pacingAnalyzer.Analyze(processRoot))
+	},
+}
+
+var pacingAnalyzer bt.PacingAnalyzer
+
+func init() {
+	processCmd.AddCommand(pacingCmd)
+
+	pacingCmd.Flags().IntVar(&pacingAnalyzer.WallOfText, "wall", 300, "Flag paragraphs longer than this many words")
+	pacingCmd.Flags().IntVar(&pacingAnalyzer.RunLength, "run", 4, "Flag this many sentences in a row of the same length")
+	pacingCmd.Flags().IntVar(&pacingAnalyzer.RunTolerance, "tolerance", 2, "Sentences within this many words count as the same length")
+}
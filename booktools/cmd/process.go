@@ -15,13 +15,14 @@
 package cmd
 
 import (
-	"bufio"
+	"fmt"
 	"io"
 	"io/ioutil"
 	"log"
 	"os"
 	"regexp"
 	"strings"
+	"time"
 
 	bt "github.com/TheGrum/booktools"
 
@@ -33,27 +34,47 @@ var processCmd = &cobra.Command{
 	Use:   "process",
 	Short: "Process the specified file",
 	Long: `Reads the specified file, tokenizes and chunks it
-in preparation for further procssing.`,
+in preparation for further procssing. Several files are read
+one after another as a single manuscript. With no files, those
+listed in the project file are read, or otherwise standard input.
+
+With --watch, the command runs again whenever one of the
+files changes, after printing what changed.`,
 	PersistentPreRun: func(cmd *cobra.Command, args []string) {
-		if len(args) == 0 {
-			Process(os.Stdin)
+		useProject(cmd, args)
+		if len(args) == 0 && len(project.Files) > 0 {
+			args = project.Paths()
 		}
-		for _, arg := range args {
-			if arg == "-" {
-				processRoot = Process(os.Stdin)
-			} else {
-				file, err := os.Open(arg)
-				if err != nil {
-					log.Fatalf("Error opening file to process: %v", err)
-				}
-				processRoot = Process(file)
-			}
+		processFiles = args
+		processRoot = load(args)
+		logProgress()
+	},
+	PersistentPostRun: func(cmd *cobra.Command, args []string) {
+		if !watch || cmd == serveCmd {
+			return
 		}
+		watchFiles(func(old *bt.Chunk, new *bt.Chunk) {
+			fmt.Fprintf(os.Stderr, "\n%v changed: %v\n\n", processFile, bt.Compare(old, new))
+			cmd.Run(cmd, args)
+		})
 	},
 }
 
 var processRoot *bt.Chunk
+
+// processFile and processText are the names and contents of the files
+// processRoot was read from, and processSources where each begins in
+// processText, for reporting diagnostics against.
+var processFile string
+var processText string
+var processSources []bt.SourceFile
+
+// processFiles are the files named on the command line.
+var processFiles []string
+var watch bool
+var watchInterval time.Duration
 var chapterRegex string
+var outputFormat string
 
 func init() {
 	rootCmd.AddCommand(processCmd)
@@ -67,61 +88,119 @@ func init() {
 	// Cobra supports local flags which will only run when this command
 	// is called directly, e.g.:
 	// processCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
+	processCmd.PersistentFlags().StringVarP(&outputFormat, "format", "f", "text", "Output format, one of "+strings.Join(bt.Formats, ", "))
 	processCmd.PersistentFlags().StringVarP(&chapterRegex, "chapterRegex", "r", "", "Regular expression which if matched on a line will trigger a chapter.")
+	processCmd.PersistentFlags().BoolVar(&watch, "watch", false, "Run again, printing what changed, whenever one of the files changes")
+	processCmd.PersistentFlags().DurationVar(&watchInterval, "watchInterval", time.Second, "How often to check the files for changes when watching")
 }
 
-func Process(input io.Reader) *bt.Chunk {
-	data, err := ioutil.ReadAll(input)
+// load reads the files in args, one after another, as a single
+// manuscript, reading standard input for "-" or where there are none.
+func load(args []string) *bt.Chunk {
+	text, sources, err := readFiles(args)
 	if err != nil {
-		log.Fatal(err)
+		log.Fatalf("Error opening file to process: %v", err)
 	}
-	/*
-		file, err := os.Open(filename)
-		if err != nil {
-			log.Fatal(err)
-		}
-		defer file.Close()
-	*/
-
-	chunks := make(chan *bt.Chunk, 10)
-	out := make(chan *bt.Chunk)
+	return parseFiles(text, sources)
+}
 
-	var reg *regexp.Regexp
-	if chapterRegex != "" {
-		reg, err = regexp.Compile(chapterRegex)
+// readFiles reads the files in args into a single text, noting where
+// each begins in it.
+func readFiles(args []string) (string, []bt.SourceFile, error) {
+	if len(args) == 0 {
+		args = []string{"-"}
+	}
+	sb := strings.Builder{}
+	sources := make([]bt.SourceFile, 0, len(args))
+	for i, arg := range args {
+		if i > 0 {
+			// Keep the last paragraph of one file apart from the
+			// first of the next
+			sb.WriteString("\n\n")
+		}
+		name, input := arg, io.Reader(os.Stdin)
+		if arg == "-" {
+			name = "stdin"
+		} else {
+			file, err := os.Open(arg)
+			if err != nil {
+				return "", nil, err
+			}
+			defer file.Close()
+			input = file
+		}
+		data, err := ioutil.ReadAll(input)
 		if err != nil {
-			log.Fatalf("Failed to compile chapter-matching regular expression [%v]", chapterRegex)
+			return "", nil, err
 		}
+		sources = append(sources, bt.SourceFile{Name: name, Start: sb.Len()})
+		sb.Write(data)
 	}
+	return sb.String(), sources, nil
+}
 
-	chunker := bt.NewChunker(strings.NewReader(string(data)), chunks)
-	chunker.OnBeforeSentence = func(c *bt.Chunker, s string) {
-		if !(reg == nil) {
-			if reg.Match([]byte(s)) {
-				c.Chapter()
-			}
-		} else {
-			// Here, define what counts as a new Chapter
-			if strings.HasPrefix(strings.TrimSpace(s), "Chapter ") {
-				c.Chapter()
-			} else if strings.Contains(s, "# Part") || strings.Contains(s, "#Part") {
-				c.Chapter()
-			} else if strings.HasSuffix(s, "2011") {
-				c.Chapter()
-			}
+// parseFiles parses the text read by readFiles, making it the one
+// diagnostics are reported against.
+func parseFiles(text string, sources []bt.SourceFile) *bt.Chunk {
+	names := make([]string, len(sources))
+	for i, s := range sources {
+		names[i] = s.Name
+	}
+	processFile = strings.Join(names, ", ")
+	processSources = sources
+	return Process(strings.NewReader(text))
+}
+
+// watchFiles reads the files again each time one of them changes,
+// passing the trees from before and after to changed. Where they cannot
+// be read, as while a file is being saved, the error is logged and the
+// next change waited for. It returns only if there are no files to
+// watch.
+func watchFiles(changed func(old *bt.Chunk, new *bt.Chunk)) {
+	paths := make([]string, 0, len(processFiles))
+	for _, p := range processFiles {
+		if p != "-" {
+			paths = append(paths, p)
 		}
 	}
-	scanner := bufio.NewScanner(chunker)
-	go bt.DigestChunks(chunks, out)
-	for scanner.Scan() {
-		//fmt.Println("[" + scanner.Text() + "]")
+	if len(paths) == 0 {
+		log.Print("Not watching, as there are no files to watch")
+		return
 	}
+	w := bt.NewWatcher(watchInterval, paths...)
+	for w.Wait(nil) {
+		text, sources, err := readFiles(processFiles)
+		if err != nil {
+			log.Printf("Error reading changed files: %v", err)
+			continue
+		}
+		old := processRoot
+		processRoot = parseFiles(text, sources)
+		logProgress()
+		changed(old, processRoot)
+	}
+}
 
-	if err := scanner.Err(); err != nil {
+func Process(input io.Reader) *bt.Chunk {
+	data, err := ioutil.ReadAll(input)
+	if err != nil {
 		log.Fatal(err)
 	}
+	processText = string(data)
+	return bt.Parse(processText, chapterStart())
+}
 
-	//fmt.Println("Fetching root")
-	root := <-out
-	return root
+// chapterStart returns the test for the start of a chapter given by
+// --chapterRegex, or nil for the default.
+func chapterStart() func(s string) bool {
+	if chapterRegex == "" {
+		return nil
+	}
+	reg, err := regexp.Compile(chapterRegex)
+	if err != nil {
+		log.Fatalf("Failed to compile chapter-matching regular expression [%v]", chapterRegex)
+	}
+	return func(s string) bool {
+		return reg.Match([]byte(s))
+	}
 }
@@ -0,0 +1,100 @@
+// Copyright © 2018 Howard C. Shaw III <howardcshaw@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"log"
+	"path/filepath"
+	"time"
+
+	bt "github.com/TheGrum/booktools"
+
+	"github.com/spf13/cobra"
+)
+
+// progressCmd represents the progress command
+var progressCmd = &cobra.Command{
+	Use:   "progress",
+	Short: "Shows progress towards the word-count targets",
+	Long: `Shows the words written each day, the current streak of days on which
+words were written, the pace of writing over the last fortnight and the
+date the target will be reached at that pace, and each chapter's word
+count against its target.
+
+Targets are read from the manuscript's booktools.yaml:
+
+  targets:
+    words: 90000          # the whole book
+    deadline: 2027-03-01
+    chapterWords: 3000    # each chapter, unless listed
+    chapters:
+      1: 4500
+
+The word count is logged in .booktools-progress.csv beside the
+project file each time booktools processes the files it lists.
+Nothing is logged for a manuscript without a project file.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		entries, err := bt.ReadProgress(progressLog())
+		if err != nil {
+			log.Fatalf("Error reading progress: %v", err)
+		}
+		printResult(bt.Progress(processRoot, project.Targets, entries, time.Now()))
+	},
+}
+
+func init() {
+	processCmd.AddCommand(progressCmd)
+}
+
+// progressLog is the path of the project's progress log, or "" where
+// there is no project file.
+func progressLog() string {
+	if project.Path() == "" {
+		return ""
+	}
+	return filepath.Join(project.Dir(), bt.ProgressFile)
+}
+
+// logProgress appends the word count of processRoot to the progress
+// log, where what was processed is the project's manuscript.
+func logProgress() {
+	path := progressLog()
+	if path == "" || !sameFiles(processFiles, project.Paths()) {
+		return
+	}
+	err := bt.AppendProgress(path, processRoot.GetWordCount(), time.Now())
+	if err != nil {
+		log.Printf("Error logging progress: %v", err)
+	}
+}
+
+// sameFiles reports whether a and b name the same files in the same
+// order.
+func sameFiles(a []string, b []string) bool {
+	if len(a) != len(b) || len(a) == 0 {
+		return false
+	}
+	for i := range a {
+		pa, err := filepath.Abs(a[i])
+		if err != nil {
+			return false
+		}
+		pb, err := filepath.Abs(b[i])
+		if err != nil || pa != pb {
+			return false
+		}
+	}
+	return true
+}
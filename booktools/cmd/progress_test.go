@@ -0,0 +1,68 @@
+// Copyright © 2018 Howard C. Shaw III <howardcshaw@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	bt "github.com/TheGrum/booktools"
+)
+
+func TestLogProgress(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name string, text string) string {
+		path := filepath.Join(dir, name)
+		if err := ioutil.WriteFile(path, []byte(text), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	book := write("book.txt", "Chapter 1\n\nShe slept.\n")
+	other := write("notes.txt", "Chapter 1\n\nHe woke.\n")
+	projectPath := write(bt.ProjectFile, "files: [book.txt]\n")
+	loaded, err := bt.LoadProject(projectPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	saved := project
+	defer func() { project = saved }()
+
+	tests := []struct {
+		name    string
+		project *bt.Project
+		files   []string
+		logged  bool
+	}{
+		{"no project file", &bt.Project{}, []string{book}, false},
+		{"other files", loaded, []string{other}, false},
+		{"project and other files", loaded, []string{book, other}, false},
+		{"project files", loaded, []string{book}, true},
+	}
+	logPath := filepath.Join(dir, bt.ProgressFile)
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			os.Remove(logPath)
+			project, processFiles = test.project, test.files
+			processRoot = bt.Parse("Chapter 1\n\nShe slept.\n", nil)
+			logProgress()
+			if _, err := os.Stat(logPath); (err == nil) != test.logged {
+				t.Errorf("logged %v, want %v", err == nil, test.logged)
+			}
+		})
+	}
+}
@@ -0,0 +1,92 @@
+// Copyright © 2018 Howard C. Shaw III <howardcshaw@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"log"
+	"path/filepath"
+	"strconv"
+
+	bt "github.com/TheGrum/booktools"
+
+	"github.com/spf13/cobra"
+)
+
+// project is the project file of the manuscript being worked on, which
+// is empty where there is none.
+var project = &bt.Project{}
+
+// useProject reads the project file named by --config, or otherwise the
+// one found in the directory of the first file in args or above it,
+// and uses its settings wherever the flags of cmd were not given.
+func useProject(cmd *cobra.Command, args []string) {
+	path := cfgFile
+	if path == "" {
+		dir := "."
+		if len(args) > 0 && args[0] != "-" {
+			dir = filepath.Dir(args[0])
+		}
+		path = bt.FindProject(dir)
+	}
+	if path == "" {
+		return
+	}
+	p, err := bt.LoadProject(path)
+	if err != nil {
+		log.Fatalf("Error reading project file: %v", err)
+	}
+	project = p
+	p.Apply()
+
+	setDefault(cmd, "format", p.Format)
+	setDefault(cmd, "chapterRegex", p.ChapterRegex)
+	rules := make([]string, len(p.Lint.Rules))
+	for i, r := range p.Lint.Rules {
+		rules[i] = p.Resolve(r)
+	}
+	setDefault(cmd, "rules", rules...)
+	setDefault(cmd, "pack", p.Lint.Packs...)
+	setDefault(cmd, "enable", p.Lint.Enable...)
+	setDefault(cmd, "disable", p.Lint.Disable...)
+	if cmd == serveCmd {
+		if p.Server.Port > 0 {
+			setDefault(cmd, "servicePort", strconv.Itoa(p.Server.Port))
+		}
+		if p.Server.Theme != "" {
+			setDefault(cmd, "theme", p.Resolve(p.Server.Theme))
+		}
+		if p.Server.Watch {
+			setDefault(cmd, "watch", "true")
+		}
+	}
+}
+
+// setDefault sets the flag of cmd called name to values, unless it was
+// given on the command line, or cmd has no such flag.
+func setDefault(cmd *cobra.Command, name string, values ...string) {
+	f := cmd.Flags().Lookup(name)
+	if f == nil || f.Changed {
+		return
+	}
+	for _, v := range values {
+		if v == "" {
+			continue
+		}
+		err := f.Value.Set(v)
+		if err != nil {
+			log.Fatalf("Error in project file: %v: %v", name, err)
+		}
+	}
+}
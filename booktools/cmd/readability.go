@@ -0,0 +1,41 @@
+// Copyright © 2018 Howard C. Shaw III <howardcshaw@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	bt "github.com/TheGrum/booktools"
+
+	"github.com/spf13/cobra"
+)
+
+// readabilityCmd represents the readability command
+var readabilityCmd = &cobra.Command{
+	Use:   "readability",
+	Short: "Scores the readability of each chapter and section",
+	Long: `Scores the readability of the work, each chapter and each section
+with the Flesch Reading Ease, Flesch-Kincaid grade, Gunning Fog,
+SMOG, Coleman-Liau and Automated Readability indexes.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		printResult(bt.ReadabilityAnalyzer{IncludeSections: readabilitySections}.Analyze(processRoot))
+	},
+}
+
+var readabilitySections bool
+
+func init() {
+	processCmd.AddCommand(readabilityCmd)
+
+	readabilityCmd.Flags().BoolVarP(&readabilitySections, "sections", "s", true, "Include each section")
+}
@@ -0,0 +1,53 @@
+// Copyright © 2018 Howard C. Shaw III <howardcshaw@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+// TestHelp runs --help on every command, which fails if the flags of a
+// command clash with those it inherits.
+func TestHelp(t *testing.T) {
+	addAnalyzerCommands()
+	var visit func(c *cobra.Command)
+	visit = func(c *cobra.Command) {
+		path := strings.Fields(c.CommandPath())[1:]
+		t.Run(strings.Join(append([]string{"booktools"}, path...), " "), func(t *testing.T) {
+			defer func() {
+				if r := recover(); r != nil {
+					t.Errorf("panic: %v", r)
+				}
+			}()
+			out := &bytes.Buffer{}
+			rootCmd.SetOutput(out)
+			rootCmd.SetArgs(append(path, "--help"))
+			if err := rootCmd.Execute(); err != nil {
+				t.Errorf("error: %v", err)
+			}
+			if !strings.Contains(out.String(), "Usage:") {
+				t.Errorf("no usage in %q", out.String())
+			}
+		})
+		for _, sub := range c.Commands() {
+			visit(sub)
+		}
+	}
+	visit(rootCmd)
+}
@@ -0,0 +1,77 @@
+// Copyright © 2018 Howard C. Shaw III <howardcshaw@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"log"
+
+	bt "github.com/TheGrum/booktools"
+
+	"github.com/spf13/cobra"
+)
+
+// scenesCmd represents the scenes command
+var scenesCmd = &cobra.Command{
+	Use:   "scenes",
+	Short: "Lists the sections with their metadata, such as point of view and location",
+	Long: `Lists each section, or scene, with its word count, opening and the
+metadata written at its start, as YAML front matter after the "---"
+marker which starts it:
+
+  ---
+  pov: Anna
+  location: Paris
+  tags: [flashback, rain]
+  ---
+
+or in a comment block at the start of the section, or after its
+chapter heading:
+
+  <!--
+  pov: Anna
+  status: draft
+  -->
+
+Metadata is not counted as words of the manuscript.
+
+--where keeps the scenes whose field has a value, as in pov=Anna, or
+has not, as in status!=final; a field alone keeps the scenes which
+have it, and !field those which have not. Of a list, such as tags, any
+value matches. --groupBy totals the scenes and words for each value of
+a field:
+
+  booktools process scenes --where status!=final --groupBy pov book.txt`,
+	Run: func(cmd *cobra.Command, args []string) {
+		a := bt.SceneAnalyzer{GroupBy: scenesGroupBy}
+		for _, w := range scenesWhere {
+			f, err := bt.ParseSceneFilter(w)
+			if err != nil {
+				log.Fatal(err)
+			}
+			a.Where = append(a.Where, f)
+		}
+		printResult(a.Analyze(processRoot))
+	},
+}
+
+var scenesWhere []string
+var scenesGroupBy string
+
+func init() {
+	processCmd.AddCommand(scenesCmd)
+
+	scenesCmd.Flags().StringArrayVar(&scenesWhere, "where", nil, "Keep the scenes matching field=value, field!=value, field or !field")
+	scenesCmd.Flags().StringVar(&scenesGroupBy, "groupBy", "", "Field to total the scenes and words by")
+}
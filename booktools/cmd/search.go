@@ -0,0 +1,78 @@
+// Copyright © 2018 Howard C. Shaw III <howardcshaw@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"log"
+
+	bt "github.com/TheGrum/booktools"
+
+	"github.com/spf13/cobra"
+)
+
+// searchCmd represents the search command
+var searchCmd = &cobra.Command{
+	Use:   "search",
+	Short: "Searches the text for a word, phrase or expression",
+	Long: `Searches the text for a word, phrase or regular expression, and
+lists each match with the words around it and the address
+(chapter.section.paragraph.sentence) at which it was found.
+
+With --boolean, the query may combine words and "quoted phrases"
+with AND, OR, NOT, NEAR/n and SAME sentence|paragraph|section,
+and each paragraph (or larger unit, with SAME) which matches is
+listed with the matching words in brackets:
+
+  booktools process search -b -q 'Anna NEAR/5 knife' book.txt`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if searchBoolean {
+			q, err := bt.ParseQuery(searchQuery, searchOptions)
+			if err != nil {
+				log.Fatal(err)
+			}
+			if searchScope != "" {
+				q.Scope = bt.StringToUnit(searchScope)
+				if q.Scope < bt.Sentence || q.Scope > bt.Chapter {
+					log.Fatalf("Unknown search scope %v", searchScope)
+				}
+			}
+			printResult(q.Find(processRoot))
+			return
+		}
+		results, err := bt.Search(processRoot, searchQuery, searchOptions)
+		if err != nil {
+			log.Fatal(err)
+		}
+		printResult(results)
+	},
+}
+
+var searchQuery string
+var searchOptions bt.SearchOptions
+var searchBoolean bool
+var searchScope string
+
+func init() {
+	processCmd.AddCommand(searchCmd)
+
+	searchCmd.Flags().StringVarP(&searchQuery, "query", "q", "", "Word, phrase or expression to search for")
+	searchCmd.Flags().BoolVarP(&searchOptions.Regexp, "regexp", "e", false, "Treat the query as a regular expression")
+	searchCmd.Flags().BoolVar(&searchOptions.MatchCase, "matchCase", false, "Match upper and lower case exactly")
+	searchCmd.Flags().BoolVar(&searchOptions.MatchDiacritics, "matchDiacritics", false, "Match accented letters exactly")
+	searchCmd.Flags().BoolVar(&searchOptions.Stem, "stem", false, "Match other forms of the query words")
+	searchCmd.Flags().BoolVarP(&searchBoolean, "boolean", "b", false, "Treat the query as a boolean and proximity expression")
+	searchCmd.Flags().StringVar(&searchScope, "scope", "", "Unit in which a boolean query must match (default paragraph)")
+	searchCmd.Flags().IntVarP(&searchOptions.Context, "context", "c", 6, "Words of context to show around each match")
+}
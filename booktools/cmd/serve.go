@@ -16,7 +16,9 @@ package cmd
 
 import (
 	"fmt"
+	"log"
 
+	bt "github.com/TheGrum/booktools"
 	sv "github.com/TheGrum/booktools/booktools/server"
 	"github.com/spf13/cobra"
 )
@@ -29,11 +31,26 @@ var serveCmd = &cobra.Command{
 	Run: func(cmd *cobra.Command, args []string) {
 		fmt.Printf(`To access booktools, open a webbrowser and
 navigate to http://localhost:%d/%s`, servicePort, "\n\n")
-		sv.Listen(processRoot, servicePort)
+		o := sv.Options{Port: servicePort, ThemeDir: themeDir, Project: project, ProgressLog: progressLog()}
+		if len(processFiles) == 1 && processFiles[0] != "-" {
+			o.History = bt.NewHistory(processFiles[0], chapterStart())
+		}
+		if watch {
+			updates := make(chan *bt.Chunk)
+			go func() {
+				watchFiles(func(old *bt.Chunk, new *bt.Chunk) {
+					log.Printf("%v changed: %v", processFile, bt.Compare(old, new))
+					updates <- new
+				})
+			}()
+			o.Updates = updates
+		}
+		sv.Serve(processRoot, o)
 	},
 }
 
 var servicePort int
+var themeDir string
 
 func init() {
 	processCmd.AddCommand(serveCmd)
@@ -48,4 +65,5 @@ func init() {
 	// is called directly, e.g.:
 	// serveCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
 	serveCmd.Flags().IntVarP(&servicePort, "servicePort", "p", 8080, "Port for webserver")
+	serveCmd.Flags().StringVar(&themeDir, "theme", "", "Directory of templates and booktools.css overriding the built-in theme")
 }
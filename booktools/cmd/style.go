@@ -0,0 +1,41 @@
+// Copyright © 2018 Howard C. Shaw III <howardcshaw@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	bt "github.com/TheGrum/booktools"
+
+	"github.com/spf13/cobra"
+)
+
+// styleCmd represents the style command
+var styleCmd = &cobra.Command{
+	Use:   "style",
+	Short: "Counts adverbs, filter words, weak verbs and passive constructions",
+	Long: `Counts -ly adverbs, filter words (saw, heard, felt, noticed...),
+weak verbs and passive constructions in each chapter. Use
+--detail to list each one with its sentence address.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		printResult(bt.StyleAnalyzer{Detail: styleDetail}.Analyze(processRoot))
+	},
+}
+
+var styleDetail bool
+
+func init() {
+	processCmd.AddCommand(styleCmd)
+
+	styleCmd.Flags().BoolVarP(&styleDetail, "detail", "d", false, "List every finding")
+}
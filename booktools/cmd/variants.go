@@ -0,0 +1,45 @@
+// Copyright © 2018 Howard C. Shaw III <howardcshaw@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	bt "github.com/TheGrum/booktools"
+
+	"github.com/spf13/cobra"
+)
+
+// variantsCmd represents the variants command
+var variantsCmd = &cobra.Command{
+	Use:   "variants",
+	Short: "Finds words and names spelled more than one way",
+	Long: `Finds words and names spelled more than one way in the book: known
+regional pairs such as grey and gray or toward and towards, words
+written both with and without hyphens, and names or uncommon words
+which differ by a single letter, such as Katherine and Katharine.
+
+Each group of spellings is listed with the number of times each is
+used and where it is first used.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		printResult(bt.VariantAnalyzer{MinLength: variantsMinLength}.Analyze(processRoot))
+	},
+}
+
+var variantsMinLength int
+
+func init() {
+	processCmd.AddCommand(variantsCmd)
+
+	variantsCmd.Flags().IntVarP(&variantsMinLength, "minLength", "m", 5, "Only compare names and words of at least this many letters for misspellings")
+}
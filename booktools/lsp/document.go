@@ -0,0 +1,253 @@
+package lsp
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	bt "github.com/TheGrum/booktools"
+)
+
+// document is an open manuscript. Its chunk tree is kept up to date
+// as the text changes, reparsing only the paragraphs an edit touches.
+type document struct {
+	uri          string
+	version      int
+	text         string
+	lineStarts   []int
+	chapterStart func(sentence string) bool
+
+	doc        *bt.Document
+	source     *bt.SourceMap
+	characters map[string]int
+}
+
+// characterAnalyzer finds the characters hover and definition know.
+var characterAnalyzer = bt.CharacterFrequencyAnalyzer{MinAppearance: 3, MinNonFirst: 1}
+
+func newDocument(uri string, version int, text string, chapterStart func(sentence string) bool) *document {
+	d := &document{uri: uri, version: version, chapterStart: chapterStart}
+	d.setText(text)
+	return d
+}
+
+func (d *document) setText(text string) {
+	d.doc = bt.NewDocument(text, d.chapterStart)
+	d.changed()
+}
+
+// changed updates the line starts after the text changes.
+func (d *document) changed() {
+	d.text = d.doc.Text
+	d.lineStarts = []int{0}
+	for i := 0; i < len(d.text); i++ {
+		if d.text[i] == '\n' {
+			d.lineStarts = append(d.lineStarts, i+1)
+		}
+	}
+	d.source = nil
+}
+
+// apply makes a change sent by the client.
+func (d *document) apply(change TextDocumentContentChangeEvent) {
+	if change.Range == nil {
+		d.setText(change.Text)
+		return
+	}
+	start, end := d.offset(change.Range.Start), d.offset(change.Range.End)
+	if end < start {
+		start, end = end, start
+	}
+	d.doc.Edit(bt.Edit{Offset: start, Length: end - start, Text: change.Text})
+	d.changed()
+}
+
+// parse returns the chunk tree of the current text.
+func (d *document) parse() *bt.Chunk {
+	if d.source == nil {
+		d.source = bt.NewSourceMap(d.filename(), d.text, d.doc.Root)
+		d.characters = make(map[string]int)
+		for _, f := range d.doc.Result(characterAnalyzer, 0).(bt.FrequencyList) {
+			d.characters[f.Name] = f.Count
+		}
+	}
+	return d.doc.Root
+}
+
+// filename is the path of a file: URI, or the URI itself otherwise.
+func (d *document) filename() string {
+	u, err := url.Parse(d.uri)
+	if err != nil || u.Scheme != "file" {
+		return d.uri
+	}
+	return u.Path
+}
+
+// lineEnd returns the offset of the end of a zero-based line, before
+// its line break.
+func (d *document) lineEnd(line int) int {
+	if line+1 < len(d.lineStarts) {
+		end := d.lineStarts[line+1] - 1
+		if end > d.lineStarts[line] && d.text[end-1] == '\r' {
+			end--
+		}
+		return end
+	}
+	return len(d.text)
+}
+
+// offset converts an LSP position into a byte offset in the text.
+func (d *document) offset(p Position) int {
+	if p.Line < 0 {
+		return 0
+	}
+	if p.Line >= len(d.lineStarts) {
+		return len(d.text)
+	}
+	off := d.lineStarts[p.Line]
+	end := d.lineEnd(p.Line)
+	for units := 0; off < end && units < p.Character; {
+		r, size := utf8.DecodeRuneInString(d.text[off:end])
+		units = units + len(utf16.Encode([]rune{r}))
+		off = off + size
+	}
+	return off
+}
+
+// position converts a byte offset in the text into an LSP position.
+func (d *document) position(offset int) Position {
+	if offset > len(d.text) {
+		offset = len(d.text)
+	}
+	line := sort.Search(len(d.lineStarts), func(i int) bool { return d.lineStarts[i] > offset }) - 1
+	prefix := d.text[d.lineStarts[line]:offset]
+	return Position{Line: line, Character: len(utf16.Encode([]rune(prefix)))}
+}
+
+// lintPosition converts the 1-based line and column, counted in
+// characters, of a lint finding into an LSP position.
+func (d *document) lintPosition(line int, column int) Position {
+	if line < 1 || line > len(d.lineStarts) {
+		return d.position(len(d.text))
+	}
+	off := d.lineStarts[line-1]
+	for i := 1; i < column && off < len(d.text); i++ {
+		_, size := utf8.DecodeRuneInString(d.text[off:])
+		off = off + size
+	}
+	return d.position(off)
+}
+
+// chunkRange returns the range of the text a chunk covers.
+func (d *document) chunkRange(c *bt.Chunk) Range {
+	return Range{Start: d.position(d.source.Offset(c)), End: d.position(d.source.End(c))}
+}
+
+// wordAt returns the whitespace-delimited word around an offset, and
+// where it starts and ends.
+func (d *document) wordAt(offset int) (string, int, int) {
+	start, end := offset, offset
+	for start > 0 {
+		r, size := utf8.DecodeLastRuneInString(d.text[:start])
+		if unicode.IsSpace(r) {
+			break
+		}
+		start = start - size
+	}
+	for end < len(d.text) {
+		r, size := utf8.DecodeRuneInString(d.text[end:])
+		if unicode.IsSpace(r) {
+			break
+		}
+		end = end + size
+	}
+	return d.text[start:end], start, end
+}
+
+// character returns the character named by the word at a position, if
+// it is one.
+func (d *document) character(p Position) (string, Range, bool) {
+	d.parse()
+	word, start, end := d.wordAt(d.offset(p))
+	name := bt.NormalizeWord(strings.Trim(word, "“”‘’()[]:;—"))
+	if _, ok := d.characters[name]; !ok || name == "" {
+		return "", Range{}, false
+	}
+	return name, Range{Start: d.position(start), End: d.position(end)}, true
+}
+
+// firstMention returns the address and word at which the character name
+// first appears.
+func (d *document) firstMention(name string) (bt.Address, *bt.Chunk) {
+	parts := strings.Fields(name)
+	var words []*bt.Chunk
+	var addresses []bt.Address
+	d.parse().Walk(bt.Word, func(a bt.Address, w *bt.Chunk) bool {
+		words = append(words, w)
+		addresses = append(addresses, a)
+		return true
+	})
+	for i := range words {
+		if i+len(parts) > len(words) {
+			break
+		}
+		match := true
+		for j, part := range parts {
+			if bt.NormalizeWord(words[i+j].Word) != part {
+				match = false
+				break
+			}
+		}
+		if match {
+			return addresses[i], words[i]
+		}
+	}
+	return bt.Address{}, nil
+}
+
+// describe summarises a character for hover.
+func (d *document) describe(name string) string {
+	a, w := d.firstMention(name)
+	if w == nil {
+		return fmt.Sprintf("**%v**\n\nNamed %d times.", name, d.characters[name])
+	}
+	p := d.position(d.source.Offset(w))
+	return fmt.Sprintf("**%v**\n\nNamed %d times, first in chapter %d at %v (line %d).", name, d.characters[name], a.Chapter, a, p.Line+1)
+}
+
+// symbols returns the outline of parts, chapters and sections.
+func (d *document) symbols() []DocumentSymbol {
+	symbols := make([]DocumentSymbol, 0)
+	root := d.parse()
+	for i, chapter := range root.Chapters() {
+		title := strings.TrimSpace(chapter.GetFirstSentence())
+		kind := symbolModule
+		if strings.HasPrefix(title, "#") {
+			kind = symbolPackage
+			title = strings.TrimSpace(strings.TrimLeft(title, "#"))
+		}
+		if title == "" {
+			title = fmt.Sprintf("Chapter %d", i+1)
+		}
+		r := d.chunkRange(chapter)
+		cs := DocumentSymbol{Name: title, Detail: fmt.Sprintf("%d words", chapter.GetWordCount()), Kind: kind, Range: r, SelectionRange: Range{Start: r.Start, End: r.Start}}
+		if len(chapter.Children) > 1 {
+			for j, section := range chapter.Children {
+				sr := d.chunkRange(section)
+				cs.Children = append(cs.Children, DocumentSymbol{
+					Name:           fmt.Sprintf("Section %v", bt.Address{Chapter: i + 1, Section: j + 1}),
+					Detail:         strings.TrimSpace(section.GetFirstSentence()),
+					Kind:           symbolNamespace,
+					Range:          sr,
+					SelectionRange: Range{Start: sr.Start, End: sr.Start},
+				})
+			}
+		}
+		symbols = append(symbols, cs)
+	}
+	return symbols
+}
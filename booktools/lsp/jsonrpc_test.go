@@ -0,0 +1,65 @@
+package lsp
+
+import (
+	"bytes"
+	"io"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestConnRead(t *testing.T) {
+	body := `{"jsonrpc":"2.0","id":1,"method":"initialize"}`
+	tests := []struct {
+		name   string
+		input  string
+		method string
+		err    bool
+	}{
+		{"message", "Content-Length: " + strconv.Itoa(len(body)) + "\r\n\r\n" + body, "initialize", false},
+		{"no length", "Content-Type: x\r\n\r\n" + body, "", true},
+		{"bad length", "Content-Length: many\r\n\r\n" + body, "", true},
+		{"negative length", "Content-Length: -1\r\n\r\n" + body, "", true},
+		{"huge length", "Content-Length: 99999999999\r\n\r\n" + body, "", true},
+		{"short body", "Content-Length: 470\r\n\r\n" + body, "", true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			m, err := newConn(strings.NewReader(test.input), &bytes.Buffer{}).read()
+			if test.err {
+				if err == nil {
+					t.Errorf("read %+v, want an error", m)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("error: %v", err)
+			}
+			if m.Method != test.method {
+				t.Errorf("method %q, want %q", m.Method, test.method)
+			}
+		})
+	}
+}
+
+func TestConnReadEOF(t *testing.T) {
+	_, err := newConn(strings.NewReader(""), &bytes.Buffer{}).read()
+	if err != io.EOF {
+		t.Errorf("error %v, want io.EOF", err)
+	}
+}
+
+func TestConnWrite(t *testing.T) {
+	out := &bytes.Buffer{}
+	c := newConn(out, out)
+	if err := c.notify("exit", nil); err != nil {
+		t.Fatal(err)
+	}
+	m, err := c.read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Method != "exit" {
+		t.Errorf("method %q, want exit", m.Method)
+	}
+}
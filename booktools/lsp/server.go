@@ -0,0 +1,241 @@
+// Package lsp serves the Language Server Protocol over stdio, so that
+// editors can show booktools' lint diagnostics, the outline of a
+// manuscript and its characters.
+package lsp
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"path"
+	"sort"
+	"strings"
+
+	bt "github.com/TheGrum/booktools"
+)
+
+// Server answers the requests of one editor for the manuscripts it has
+// open.
+type Server struct {
+	conn         *conn
+	rules        *bt.RuleSet
+	chapterStart func(sentence string) bool
+	docs         map[string]*document
+	shutdown     bool
+}
+
+// Serve reads requests from r and writes responses to w until the
+// editor exits, linting documents against rules and starting chapters
+// where chapterStart says, or as Parse does where it is nil.
+func Serve(r io.Reader, w io.Writer, rules *bt.RuleSet, chapterStart func(sentence string) bool) error {
+	if rules == nil {
+		rules = &bt.RuleSet{}
+	}
+	s := &Server{conn: newConn(r, w), rules: rules, chapterStart: chapterStart, docs: make(map[string]*document)}
+	for {
+		m, err := s.conn.read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			if _, ok := err.(*json.SyntaxError); ok {
+				s.conn.replyError(nil, codeParseError, "%v", err)
+				continue
+			}
+			return err
+		}
+		if m.Method == "exit" {
+			if !s.shutdown {
+				return errors.New("exit without shutdown")
+			}
+			return nil
+		}
+		err = s.handle(m)
+		if err != nil {
+			log.Printf("Error handling %v: %v", m.Method, err)
+		}
+	}
+}
+
+// handle dispatches a message to its handler, replying to requests with
+// the result. Notifications which are not understood are ignored.
+func (s *Server) handle(m *message) error {
+	var result interface{}
+	var err error
+	switch m.Method {
+	case "initialize":
+		result = InitializeResult{
+			Capabilities: ServerCapabilities{
+				TextDocumentSync:        TextDocumentSyncOptions{OpenClose: true, Change: syncIncremental, Save: SaveOptions{IncludeText: true}},
+				HoverProvider:           true,
+				DocumentSymbolProvider:  true,
+				DefinitionProvider:      true,
+				WorkspaceSymbolProvider: true,
+			},
+			ServerInfo: ServerInfo{Name: "booktools"},
+		}
+	case "shutdown":
+		s.shutdown = true
+	case "textDocument/didOpen":
+		p := DidOpenTextDocumentParams{}
+		if err = json.Unmarshal(m.Params, &p); err == nil {
+			d := newDocument(p.TextDocument.URI, p.TextDocument.Version, p.TextDocument.Text, s.chapterStart)
+			s.docs[d.uri] = d
+			err = s.publish(d)
+		}
+	case "textDocument/didChange":
+		p := DidChangeTextDocumentParams{}
+		if err = json.Unmarshal(m.Params, &p); err == nil {
+			if d, ok := s.docs[p.TextDocument.URI]; ok {
+				for _, change := range p.ContentChanges {
+					d.apply(change)
+				}
+				d.version = p.TextDocument.Version
+			}
+		}
+	case "textDocument/didSave":
+		p := DidSaveTextDocumentParams{}
+		if err = json.Unmarshal(m.Params, &p); err == nil {
+			if d, ok := s.docs[p.TextDocument.URI]; ok {
+				if p.Text != nil {
+					d.setText(*p.Text)
+				}
+				err = s.publish(d)
+			}
+		}
+	case "textDocument/didClose":
+		p := DidCloseTextDocumentParams{}
+		if err = json.Unmarshal(m.Params, &p); err == nil {
+			delete(s.docs, p.TextDocument.URI)
+			err = s.conn.notify("textDocument/publishDiagnostics", PublishDiagnosticsParams{URI: p.TextDocument.URI, Diagnostics: []Diagnostic{}})
+		}
+	case "textDocument/hover":
+		p := TextDocumentPositionParams{}
+		if err = json.Unmarshal(m.Params, &p); err == nil {
+			result = s.hover(p)
+		}
+	case "textDocument/definition":
+		p := TextDocumentPositionParams{}
+		if err = json.Unmarshal(m.Params, &p); err == nil {
+			result = s.definition(p)
+		}
+	case "textDocument/documentSymbol":
+		p := DocumentSymbolParams{}
+		if err = json.Unmarshal(m.Params, &p); err == nil {
+			if d, ok := s.docs[p.TextDocument.URI]; ok {
+				result = d.symbols()
+			}
+		}
+	case "workspace/symbol":
+		p := WorkspaceSymbolParams{}
+		if err = json.Unmarshal(m.Params, &p); err == nil {
+			result = s.workspaceSymbols(p.Query)
+		}
+	default:
+		if m.ID != nil {
+			return s.conn.replyError(m.ID, codeMethodNotFound, "method %v is not supported", m.Method)
+		}
+		return nil
+	}
+	if m.ID == nil {
+		return err
+	}
+	if err != nil {
+		return s.conn.replyError(m.ID, codeInvalidParams, "%v", err)
+	}
+	return s.conn.reply(m.ID, result)
+}
+
+// publish sends the lint findings for a document as diagnostics.
+func (s *Server) publish(d *document) error {
+	diagnostics := make([]Diagnostic, 0)
+	for _, f := range s.rules.Lint(d.text) {
+		start := d.lintPosition(f.Line, f.Column)
+		var r Range
+		if f.Fix != nil && f.Fix.Length > 0 {
+			r = Range{Start: d.position(f.Fix.Offset), End: d.position(f.Fix.Offset + f.Fix.Length)}
+		} else {
+			_, _, end := d.wordAt(d.offset(start))
+			r = Range{Start: start, End: d.position(end)}
+		}
+		diagnostics = append(diagnostics, Diagnostic{Range: r, Severity: lspSeverity(f.Severity), Code: f.Rule, Source: "booktools", Message: f.Message})
+	}
+	return s.conn.notify("textDocument/publishDiagnostics", PublishDiagnosticsParams{URI: d.uri, Version: d.version, Diagnostics: diagnostics})
+}
+
+func lspSeverity(severity string) int {
+	switch severity {
+	case bt.SeverityError:
+		return severityError
+	case bt.SeverityWarning:
+		return severityWarning
+	}
+	return severityInformation
+}
+
+// hover describes the character under the cursor, if there is one.
+func (s *Server) hover(p TextDocumentPositionParams) *Hover {
+	d, ok := s.docs[p.TextDocument.URI]
+	if !ok {
+		return nil
+	}
+	name, r, ok := d.character(p.Position)
+	if !ok {
+		return nil
+	}
+	return &Hover{Contents: MarkupContent{Kind: "markdown", Value: d.describe(name)}, Range: &r}
+}
+
+// definition finds the first mention of the character under the cursor.
+func (s *Server) definition(p TextDocumentPositionParams) *Location {
+	d, ok := s.docs[p.TextDocument.URI]
+	if !ok {
+		return nil
+	}
+	name, _, ok := d.character(p.Position)
+	if !ok {
+		return nil
+	}
+	_, w := d.firstMention(name)
+	if w == nil {
+		return nil
+	}
+	return &Location{URI: d.uri, Range: d.chunkRange(w)}
+}
+
+// workspaceSymbols lists the characters in every open document whose
+// names contain the query, at their first mentions.
+func (s *Server) workspaceSymbols(query string) []SymbolInformation {
+	symbols := make([]SymbolInformation, 0)
+	uris := make([]string, 0, len(s.docs))
+	for uri := range s.docs {
+		uris = append(uris, uri)
+	}
+	sort.Strings(uris)
+	query = strings.ToLower(query)
+	for _, uri := range uris {
+		d := s.docs[uri]
+		d.parse()
+		names := make([]string, 0, len(d.characters))
+		for name := range d.characters {
+			if strings.Contains(strings.ToLower(name), query) {
+				names = append(names, name)
+			}
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			_, w := d.firstMention(name)
+			if w == nil {
+				continue
+			}
+			symbols = append(symbols, SymbolInformation{
+				Name:          name,
+				Kind:          symbolObject,
+				Location:      Location{URI: uri, Range: d.chunkRange(w)},
+				ContainerName: path.Base(d.filename()),
+			})
+		}
+	}
+	return symbols
+}
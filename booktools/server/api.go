@@ -0,0 +1,168 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+
+	bt "github.com/TheGrum/booktools"
+)
+
+// ChapterInfo describes a chapter in API responses.
+type ChapterInfo struct {
+	Chapter  int           `json:"chapter"`
+	Title    string        `json:"title"`
+	Position int64         `json:"position"`
+	Length   int64         `json:"length"`
+	Stats    bt.ChunkStats `json:"stats"`
+	Text     string        `json:"text,omitempty"`
+}
+
+// ChapterStats is the per-chapter statistics returned by /api/v1/stats.
+type ChapterStats struct {
+	Chapter    int           `json:"chapter"`
+	Stats      bt.ChunkStats `json:"stats"`
+	Characters []string      `json:"characters"`
+}
+
+type apiError struct {
+	Error string `json:"error"`
+}
+
+// SendAPI serves the JSON API rooted at /api/v1/:
+//
+//	/api/v1/structure                  the outline of the work
+//	/api/v1/chapters                   every chapter's metadata
+//	/api/v1/chapters/N                 one chapter's metadata and text
+//	/api/v1/characters                 the characters in the work
+//	/api/v1/characters/frequencies     the characters and their frequencies
+//	/api/v1/stats                      per-chapter counts and top characters
+//	/api/v1/search?q=word              matches of a word, phrase or expression
+//	/api/v1/scenes?where=pov=Anna      the sections and their metadata,
+//	                                   grouped by a field with group=pov
+//	/api/v1/analysis                   the registered analyzers
+//	/api/v1/analysis/name              the result of one analyzer
+func (b BooktoolsServer) SendAPI(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		sendJSONError(w, http.StatusMethodNotAllowed, "method %v not allowed", r.Method)
+		return
+	}
+	p := path.Clean(r.URL.Path)
+	elements := strings.Split(strings.TrimPrefix(p, "/"), "/")
+	if len(elements) < 3 || elements[1] != "v1" {
+		sendJSONError(w, http.StatusNotFound, "no such endpoint %v", p)
+		return
+	}
+	elements = elements[2:]
+
+	switch elements[0] {
+	case "structure":
+		sendJSON(w, b.root.Structure(r.URL.Query().Get("sentences") == "true", 99))
+	case "chapters":
+		b.sendAPIChapters(w, r, elements[1:])
+	case "characters":
+		if len(elements) > 1 && elements[1] == "frequencies" {
+			sendJSON(w, bt.CharacterFrequencyAnalyzer{MinAppearance: 3, MinNonFirst: 1}.Analyze(b.root))
+			return
+		}
+		sendJSON(w, bt.CharacterAnalyzer{MinAppearance: 3, MinNonFirst: 1}.Analyze(b.root))
+	case "stats":
+		stats := make([]ChapterStats, 0)
+		ranked := bt.ChapterCharacterAnalyzer{TopX: 8, IncludeXthSentence: -1}.Analyze(b.root).(*bt.ChapterCharacterResult)
+		for i, c := range b.root.Chapters() {
+			stats = append(stats, ChapterStats{Chapter: i + 1, Stats: c.Stats(), Characters: ranked.Chapters[i].Characters})
+		}
+		sendJSON(w, stats)
+	case "search":
+		q := r.URL.Query().Get("q")
+		if q == "" {
+			sendJSONError(w, http.StatusBadRequest, "missing query parameter q")
+			return
+		}
+		results, err := bt.Search(b.root, q, searchOptions(r))
+		if err != nil {
+			sendJSONError(w, http.StatusBadRequest, "%v", err)
+			return
+		}
+		sendJSON(w, results)
+	case "scenes":
+		filters, err := sceneFilters(r)
+		if err != nil {
+			sendJSONError(w, http.StatusBadRequest, "%v", err)
+			return
+		}
+		sendJSON(w, bt.SceneAnalyzer{Where: filters, GroupBy: r.URL.Query().Get("group")}.Analyze(b.root))
+	case "analysis":
+		if len(elements) < 2 {
+			names := make([]string, 0)
+			for _, a := range bt.Analyzers() {
+				names = append(names, a.Name())
+			}
+			sendJSON(w, names)
+			return
+		}
+		a := bt.GetAnalyzer(elements[1])
+		if a == nil {
+			sendJSONError(w, http.StatusNotFound, "no such analyzer %v", elements[1])
+			return
+		}
+		sendJSON(w, a.Analyze(b.root))
+	default:
+		sendJSONError(w, http.StatusNotFound, "no such endpoint %v", p)
+	}
+}
+
+func (b BooktoolsServer) sendAPIChapters(w http.ResponseWriter, r *http.Request, elements []string) {
+	chapters := b.root.Chapters()
+	if len(elements) == 0 {
+		infos := make([]ChapterInfo, len(chapters))
+		for i, c := range chapters {
+			infos[i] = chapterInfo(i+1, c)
+		}
+		sendJSON(w, infos)
+		return
+	}
+	i, err := strconv.Atoi(elements[0])
+	if err != nil {
+		sendJSONError(w, http.StatusBadRequest, "could not parse chapter %v", elements[0])
+		return
+	}
+	if i < 1 || i > len(chapters) {
+		sendJSONError(w, http.StatusNotFound, "no chapter %d", i)
+		return
+	}
+	info := chapterInfo(i, chapters[i-1])
+	info.Text = chapters[i-1].String()
+	sendJSON(w, info)
+}
+
+func chapterInfo(i int, c *bt.Chunk) ChapterInfo {
+	return ChapterInfo{
+		Chapter:  i,
+		Title:    strings.TrimSpace(c.GetFirstSentence()),
+		Position: c.Position,
+		Length:   c.Length,
+		Stats:    c.Stats(),
+	}
+}
+
+func sendJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	err := json.NewEncoder(w).Encode(v)
+	if err != nil {
+		log.Printf("Error serving JSON: %v", err)
+	}
+}
+
+func sendJSONError(w http.ResponseWriter, status int, format string, args ...interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	err := json.NewEncoder(w).Encode(apiError{Error: fmt.Sprintf(format, args...)})
+	if err != nil {
+		log.Printf("Error serving JSON: %v", err)
+	}
+}
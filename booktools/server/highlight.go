@@ -0,0 +1,97 @@
+package server
+
+import (
+	"strings"
+	"sync"
+
+	bt "github.com/TheGrum/booktools"
+)
+
+// wordView is a word in the chapter view, with the classes and notes of
+// any findings which touch it.
+type wordView struct {
+	Text  string
+	Class string
+	Title string
+}
+
+// highlights collects the findings to mark on each word of a chapter.
+type highlights map[*bt.Chunk][]finding
+
+type finding struct {
+	class string
+	note  string
+}
+
+func (h highlights) add(w *bt.Chunk, class string, note string) {
+	if w != nil {
+		h[w] = append(h[w], finding{class, note})
+	}
+}
+
+// echoCache keeps the echoes of the last tree they were found in, so
+// that each chapter page does not analyse the whole book again. A new
+// tree, as when a watched manuscript changes, is analysed afresh.
+type echoCache struct {
+	mu     sync.Mutex
+	root   *bt.Chunk
+	echoes bt.EchoList
+}
+
+func (c *echoCache) get(root *bt.Chunk) bt.EchoList {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.root != root {
+		c.echoes = bt.EchoAnalyzer{Window: 30, MinLength: 4, Stem: true}.Analyze(root).(bt.EchoList)
+		c.root = root
+	}
+	return c.echoes
+}
+
+// chapterHighlights gathers the findings in the given chapter.
+func (b BooktoolsServer) chapterHighlights(chapter int) highlights {
+	h := make(highlights)
+	for _, e := range b.echoes.get(b.root) {
+		if e.Address.Chapter != chapter {
+			continue
+		}
+		h.add(e.First, e.Kind, e.String())
+		h.add(e.Second, e.Kind, e.String())
+	}
+	b.root.Walk(bt.Sentence, func(a bt.Address, s *bt.Chunk) bool {
+		if a.Chapter != chapter {
+			return a.Chapter < chapter
+		}
+		for _, f := range bt.StyleFindings(a, s) {
+			for _, w := range f.Words {
+				h.add(w, f.Kind, f.Kind+": "+f.Text)
+			}
+		}
+		return true
+	})
+	return h
+}
+
+// words lays out the words of a paragraph with their highlights.
+func (h highlights) words(paragraph *bt.Chunk) []wordView {
+	words := make([]wordView, 0)
+	for _, sentence := range paragraph.Children {
+		for _, w := range sentence.Children {
+			v := wordView{Text: w.Word}
+			classes := make([]string, 0)
+			notes := make([]string, 0)
+			seen := make(map[string]bool)
+			for _, f := range h[w] {
+				if !seen[f.class] {
+					classes = append(classes, f.class)
+					seen[f.class] = true
+				}
+				notes = append(notes, f.note)
+			}
+			v.Class = strings.Join(classes, " ")
+			v.Title = strings.Join(notes, "; ")
+			words = append(words, v)
+		}
+	}
+	return words
+}
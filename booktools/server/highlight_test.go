@@ -0,0 +1,34 @@
+package server
+
+import (
+	"testing"
+
+	bt "github.com/TheGrum/booktools"
+)
+
+func TestEchoCache(t *testing.T) {
+	text := "Chapter 1\n\nShe walked to the door and walked back.\n\nChapter 2\n\nHe waited by the window and waited.\n"
+	root := bt.Parse(text, nil)
+	c := &echoCache{}
+	first := c.get(root)
+	if len(first) == 0 {
+		t.Fatal("no echoes found")
+	}
+	if again := c.get(root); &again[0] != &first[0] {
+		t.Errorf("echoes of the same tree found again")
+	}
+	changed := bt.Parse(text+"\nShe walked on.\n", nil)
+	if again := c.get(changed); &again[0] == &first[0] {
+		t.Errorf("echoes of an old tree served for a new one")
+	}
+	b := BooktoolsServer{root: root, echoes: c}
+	h := b.chapterHighlights(2)
+	for w := range h {
+		if w.Word == "walked" {
+			t.Errorf("an echo in chapter 1 highlighted in chapter 2")
+		}
+	}
+	if len(h) == 0 {
+		t.Errorf("no highlights in chapter 2")
+	}
+}
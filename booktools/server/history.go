@@ -0,0 +1,107 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	bt "github.com/TheGrum/booktools"
+)
+
+const (
+	historyWidth  = 800
+	historyHeight = 200
+)
+
+// historyView is the git history of the manuscript, with a line chart
+// of its total word count and a sparkline of each chapter's.
+type historyView struct {
+	Error  string
+	File   string
+	Width  int
+	Height int
+	// Line is the points of an SVG polyline of the total word count.
+	Line     string
+	MaxWords int
+	Commits  []historyRow
+	Chapters []historyChapter
+}
+
+type historyRow struct {
+	bt.HistoryPoint
+	Delta int
+}
+
+type historyChapter struct {
+	Chapter   int
+	Words     int
+	Width     int
+	Height    int
+	Sparkline string
+}
+
+// SendHistory charts the word counts of each revision of the manuscript
+// committed to git.
+func (b BooktoolsServer) SendHistory(w http.ResponseWriter, r *http.Request) {
+	view := historyView{Width: historyWidth, Height: historyHeight}
+	if b.history == nil {
+		view.Error = "The history is only kept for a single manuscript file."
+		b.render(w, "history", "History", view)
+		return
+	}
+	view.File = b.history.File
+	err := b.history.Update()
+	if err != nil {
+		view.Error = err.Error()
+		b.render(w, "history", "History", view)
+		return
+	}
+	points := b.history.Points
+	for _, p := range points {
+		if p.Words > view.MaxWords {
+			view.MaxWords = p.Words
+		}
+	}
+	view.Line = historyLine(points, func(p bt.HistoryPoint) int { return p.Words }, view.MaxWords, historyWidth, historyHeight)
+	for i := len(points) - 1; i >= 0; i-- {
+		row := historyRow{HistoryPoint: points[i], Delta: points[i].Words}
+		if i > 0 {
+			row.Delta = row.Delta - points[i-1].Words
+		}
+		view.Commits = append(view.Commits, row)
+	}
+	for c := 0; c < b.history.MaxChapters(); c++ {
+		words := func(p bt.HistoryPoint) int {
+			if c < len(p.ChapterWords) {
+				return p.ChapterWords[c]
+			}
+			return 0
+		}
+		most := 0
+		for _, p := range points {
+			if words(p) > most {
+				most = words(p)
+			}
+		}
+		hc := historyChapter{Chapter: c + 1, Width: chartWidth, Height: chartHeight, Sparkline: historyLine(points, words, most, chartWidth, chartHeight)}
+		if len(points) > 0 {
+			hc.Words = words(points[len(points)-1])
+		}
+		view.Chapters = append(view.Chapters, hc)
+	}
+	b.render(w, "history", "History", view)
+}
+
+// historyLine returns the points of a polyline of value over the
+// commits, from 0 to max.
+func historyLine(points []bt.HistoryPoint, value func(p bt.HistoryPoint) int, max int, width int, height int) string {
+	l := make([]string, len(points))
+	for i, p := range points {
+		x := 0
+		if len(points) > 1 {
+			x = i * width / (len(points) - 1)
+		}
+		l[i] = fmt.Sprintf("%d,%d", x, height-scale(float64(value(p)), max, height))
+	}
+	return strings.Join(l, " ")
+}
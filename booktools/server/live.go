@@ -0,0 +1,93 @@
+package server
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+
+	bt "github.com/TheGrum/booktools"
+)
+
+// liveRoot holds the tree being served while the manuscript is watched,
+// swapped whole when it changes, and the pages listening for the
+// change.
+type liveRoot struct {
+	mu        sync.Mutex
+	root      *bt.Chunk
+	listeners map[chan string]bool
+}
+
+func newLiveRoot(root *bt.Chunk) *liveRoot {
+	return &liveRoot{root: root, listeners: make(map[chan string]bool)}
+}
+
+// current returns the tree to answer a request from. A request keeps
+// the tree it started with, however long it takes.
+func (l *liveRoot) current() *bt.Chunk {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.root
+}
+
+// swap serves root from now on and tells the listening pages what
+// changed.
+func (l *liveRoot) swap(root *bt.Chunk) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delta := bt.Compare(l.root, root)
+	l.root = root
+	for c := range l.listeners {
+		select {
+		case c <- delta.String():
+		default:
+			// The page has yet to take the last change, and will
+			// reload for that one.
+		}
+	}
+}
+
+func (l *liveRoot) listen() chan string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	c := make(chan string, 1)
+	l.listeners[c] = true
+	return c
+}
+
+func (l *liveRoot) forget(c chan string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.listeners, c)
+}
+
+// SendEvents streams a "reload" Server-Sent Event, carrying a summary
+// of the change, each time the manuscript changes.
+func (b BooktoolsServer) SendEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok || b.live == nil {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	c := b.live.listen()
+	defer b.live.forget(c)
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case delta := <-c:
+			_, err := fmt.Fprintf(w, "event: reload\ndata: %v\n\n", strings.ReplaceAll(delta, "\n", " "))
+			if err != nil {
+				log.Printf("Error sending event: %v", err)
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
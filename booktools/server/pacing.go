@@ -0,0 +1,75 @@
+package server
+
+import (
+	"fmt"
+	"strings"
+
+	bt "github.com/TheGrum/booktools"
+)
+
+const (
+	chartWidth  = 400
+	chartHeight = 40
+)
+
+// pacingView is a chapter's pacing along with the coordinates of its
+// charts: a sparkline of sentence lengths and a box plot of paragraph
+// lengths.
+type pacingView struct {
+	bt.ChapterPacing
+	Width  int
+	Height int
+	// Sparkline is the points of an SVG polyline.
+	Sparkline string
+	// Whisker ends, box edges and median of the box plot.
+	BoxMin    int
+	BoxP25    int
+	BoxMedian int
+	BoxP75    int
+	BoxMax    int
+}
+
+func newPacingView(p bt.ChapterPacing, maxSentence int, maxParagraph int) pacingView {
+	v := pacingView{ChapterPacing: p, Width: chartWidth, Height: chartHeight}
+	points := make([]string, len(p.SentenceLengths))
+	for i, n := range p.SentenceLengths {
+		x := 0
+		if len(p.SentenceLengths) > 1 {
+			x = i * chartWidth / (len(p.SentenceLengths) - 1)
+		}
+		points[i] = fmt.Sprintf("%d,%d", x, chartHeight-scale(float64(n), maxSentence, chartHeight))
+	}
+	v.Sparkline = strings.Join(points, " ")
+	d := p.Paragraphs
+	v.BoxMin = scale(float64(d.Min), maxParagraph, chartWidth)
+	v.BoxP25 = scale(d.P25, maxParagraph, chartWidth)
+	v.BoxMedian = scale(d.Median, maxParagraph, chartWidth)
+	v.BoxP75 = scale(d.P75, maxParagraph, chartWidth)
+	v.BoxMax = scale(float64(d.Max), maxParagraph, chartWidth)
+	return v
+}
+
+// scale maps v from 0..max onto 0..size.
+func scale(v float64, max int, size int) int {
+	if max == 0 {
+		return 0
+	}
+	return int(v * float64(size) / float64(max))
+}
+
+func pacingViews(report bt.PacingReport) []pacingView {
+	maxSentence, maxParagraph := 0, 0
+	for _, p := range report {
+		if p.Sentences.Max > maxSentence {
+			maxSentence = p.Sentences.Max
+		}
+		if p.Paragraphs.Max > maxParagraph {
+			maxParagraph = p.Paragraphs.Max
+		}
+	}
+	views := make([]pacingView, len(report))
+	for i, p := range report {
+		views[i] = newPacingView(p, maxSentence, maxParagraph)
+	}
+	return views
+}
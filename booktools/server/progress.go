@@ -0,0 +1,77 @@
+package server
+
+import (
+	"log"
+	"time"
+
+	bt "github.com/TheGrum/booktools"
+)
+
+const (
+	barWidth  = 200
+	barHeight = 12
+)
+
+// progressView is the progress dashboard: the progress report with the
+// coordinates of a bar chart of the words written each day of the last
+// fortnight, and a bar for each chapter's count against its target.
+type progressView struct {
+	*bt.ProgressReport
+	Width    int
+	Height   int
+	Days     []dayBar
+	Chapters []chapterBar
+}
+
+type dayBar struct {
+	bt.DayProgress
+	X, Y, Width, Height int
+}
+
+type chapterBar struct {
+	bt.ChapterProgress
+	Width  int
+	Height int
+	Fill   int
+}
+
+func newProgressView(r *bt.ProgressReport) progressView {
+	v := progressView{ProgressReport: r, Width: chartWidth, Height: chartHeight}
+	recent := r.Recent()
+	most := 0
+	for _, d := range recent {
+		if d.Written > most {
+			most = d.Written
+		}
+	}
+	for i, d := range recent {
+		b := dayBar{DayProgress: d, Width: chartWidth/bt.PaceDays - 2, X: i * chartWidth / bt.PaceDays}
+		if d.Written > 0 {
+			b.Height = scale(float64(d.Written), most, chartHeight)
+		}
+		b.Y = chartHeight - b.Height
+		v.Days = append(v.Days, b)
+	}
+	for _, c := range r.Chapters {
+		b := chapterBar{ChapterProgress: c, Width: barWidth, Height: barHeight}
+		b.Fill = scale(float64(c.Words), c.Target, barWidth)
+		if b.Fill > barWidth {
+			b.Fill = barWidth
+		}
+		v.Chapters = append(v.Chapters, b)
+	}
+	return v
+}
+
+// progress reports the progress of the work towards its targets.
+func (b BooktoolsServer) progress() progressView {
+	targets := bt.Targets{}
+	if b.project != nil {
+		targets = b.project.Targets
+	}
+	entries, err := bt.ReadProgress(b.progressLog)
+	if err != nil {
+		log.Printf("Error reading progress: %v", err)
+	}
+	return newProgressView(bt.Progress(b.root, targets, entries, time.Now()))
+}
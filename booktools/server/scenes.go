@@ -0,0 +1,134 @@
+package server
+
+import (
+	"net/http"
+	"net/url"
+
+	bt "github.com/TheGrum/booktools"
+)
+
+// scenesView is the sections of the manuscript with their metadata,
+// filtered by the where parameters and grouped by the group parameter
+// of the request. Every value links to the scenes filtered by it.
+type scenesView struct {
+	Error   string
+	Fields  []fieldLink
+	Where   []filterLink
+	GroupBy string
+	Groups  []groupRow
+	Scenes  []sceneRow
+	Words   int
+	Clear   string
+}
+
+type fieldLink struct {
+	Field string
+	Group string
+}
+
+type filterLink struct {
+	Filter string
+	Remove string
+}
+
+type valueLink struct {
+	Value string
+	Href  string
+}
+
+type groupRow struct {
+	valueLink
+	Scenes int
+	Words  int
+}
+
+type sceneRow struct {
+	Address string
+	Words   int
+	Opening string
+	Cells   [][]valueLink
+}
+
+// sceneFilters reads the where parameters of a request.
+func sceneFilters(r *http.Request) ([]bt.SceneFilter, error) {
+	filters := make([]bt.SceneFilter, 0)
+	for _, w := range r.URL.Query()["where"] {
+		f, err := bt.ParseSceneFilter(w)
+		if err != nil {
+			return nil, err
+		}
+		filters = append(filters, f)
+	}
+	return filters, nil
+}
+
+// scenesHref links to the scenes page with the given filters and
+// grouping.
+func scenesHref(filters []bt.SceneFilter, group string) string {
+	q := url.Values{}
+	for _, f := range filters {
+		q.Add("where", f.String())
+	}
+	if group != "" {
+		q.Set("group", group)
+	}
+	if len(q) == 0 {
+		return "/scenes/"
+	}
+	return "/scenes/?" + q.Encode()
+}
+
+// SendScenes tabulates the scenes of the manuscript by their metadata.
+func (b BooktoolsServer) SendScenes(w http.ResponseWriter, r *http.Request) {
+	view := scenesView{GroupBy: r.URL.Query().Get("group"), Clear: "/scenes/"}
+	filters, err := sceneFilters(r)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		view.Error = err.Error()
+		b.render(w, "scenes", "Scenes", view)
+		return
+	}
+	all := bt.Scenes(b.root)
+	scenes := all.Filter(filters...)
+	view.Words = scenes.Words()
+	// narrow links to the scenes which also match field=value.
+	narrow := func(field string, value string) string {
+		filter := bt.SceneFilter{Field: field, Value: value}
+		for _, f := range filters {
+			if f.String() == filter.String() {
+				return scenesHref(filters, view.GroupBy)
+			}
+		}
+		return scenesHref(append(append([]bt.SceneFilter{}, filters...), filter), view.GroupBy)
+	}
+
+	fields := all.Fields()
+	for _, field := range fields {
+		view.Fields = append(view.Fields, fieldLink{Field: field, Group: scenesHref(filters, field)})
+	}
+	for i, f := range filters {
+		rest := append(append([]bt.SceneFilter{}, filters[:i]...), filters[i+1:]...)
+		view.Where = append(view.Where, filterLink{Filter: f.String(), Remove: scenesHref(rest, view.GroupBy)})
+	}
+	if view.GroupBy != "" {
+		for _, g := range scenes.Group(view.GroupBy).Groups {
+			row := groupRow{valueLink: valueLink{Value: g.Value}, Scenes: len(g.Scenes), Words: g.Words}
+			if g.Value != "" {
+				row.Href = narrow(view.GroupBy, g.Value)
+			}
+			view.Groups = append(view.Groups, row)
+		}
+	}
+	for _, s := range scenes {
+		row := sceneRow{Address: s.Address.String(), Words: s.Words, Opening: s.Opening}
+		for _, field := range fields {
+			cell := make([]valueLink, 0)
+			for _, v := range bt.MetaValues(s.Meta[field]) {
+				cell = append(cell, valueLink{Value: v, Href: narrow(field, v)})
+			}
+			row.Cells = append(row.Cells, cell)
+		}
+		view.Scenes = append(view.Scenes, row)
+	}
+	b.render(w, "scenes", "Scenes", view)
+}
@@ -5,6 +5,7 @@ import (
 	"log"
 	"net/http"
 	"path"
+	"sort"
 	"strconv"
 	"strings"
 
@@ -12,16 +13,110 @@ import (
 )
 
 type BooktoolsServer struct {
-	root *bt.Chunk
+	root  *bt.Chunk
+	theme *Theme
+	// live holds the current tree where the manuscript is watched.
+	live        *liveRoot
+	echoes      *echoCache
+	history     *bt.History
+	project     *bt.Project
+	progressLog string
+}
+
+// page is the data passed to every page template. Data holds whatever
+// the individual page needs.
+type page struct {
+	Title   string
+	Heading string
+	Data    interface{}
+	// Live pages reload when the manuscript changes.
+	Live bool
+}
+
+type structureLine struct {
+	Indent int
+	Unit   string
+	Text   string
+}
+
+type paragraphView struct {
+	Address string
+	Words   []wordView
+}
+
+type sectionView struct {
+	Address    string
+	Meta       []metaView
+	Paragraphs []paragraphView
+}
+
+// metaView is one field of a section's metadata, linking to the scenes
+// grouped by the field.
+type metaView struct {
+	Field string
+	Value string
+	Href  string
+}
+
+type chapterView struct {
+	Chapter  int
+	Title    string
+	Chapters int
+	Prev     int
+	Next     int
+	// Percentage of the work's words read before and by the end of
+	// this chapter.
+	StartPercent int
+	EndPercent   int
+	Sections     []sectionView
+	// Toggles are the classes of highlight which can be switched off.
+	Toggles []string
+}
+
+type chapterCharactersRow struct {
+	bt.ChapterSummary
+	Readability bt.Readability
+}
+
+type matchCell struct {
+	Name  string
+	Count int
+}
+
+type matchRow struct {
+	Chapter int
+	Cells   []matchCell
+}
+
+type matchesView struct {
+	Names    []string
+	Chapters []matchRow
+}
+
+type searchView struct {
+	Query   string
+	Options bt.SearchOptions
+	Boolean bool
+	Error   string
+	Results bt.SearchResults
+	Hits    bt.QueryResults
+}
+
+type analysisView struct {
+	Name    string
+	Columns []string
+	Rows    [][]string
 }
 
 func (b BooktoolsServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	log.Printf("Received request (%v)\n", r.URL.Path)
+	if b.live != nil {
+		b.root = b.live.current()
+	}
 	p := path.Clean(r.URL.Path)
 	elements := strings.Split(strings.TrimPrefix(strings.ToLower(p), "/"), "/")
 	if elements == nil {
-		w.WriteHeader(http.StatusNotFound)
-		fmt.Fprint(w, "404 Page Not Found")
+		http.NotFound(w, r)
 		return
 	}
 	action := elements[0]
@@ -29,7 +124,7 @@ func (b BooktoolsServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	log.Printf("Checking possible action: (%v) elements:[%v]", action, elements)
 	switch action {
 	case "booktools.css":
-		SendCSS(w, r)
+		b.SendCSS(w, r)
 	case "edit":
 		log.Print("edit")
 		p = path.Dir(p)
@@ -45,52 +140,62 @@ func (b BooktoolsServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	case "chapter":
 		log.Print("chapter")
 		b.SendChapter(w, r)
+	case "search":
+		log.Print("search")
+		b.SendSearch(w, r)
+	case "readability":
+		log.Print("readability")
+		b.render(w, "readability", "Readability", bt.ReadabilityAnalyzer{IncludeSections: true}.Analyze(b.root))
+	case "pacing":
+		log.Print("pacing")
+		report := bt.PacingAnalyzer{WallOfText: 300, RunLength: 4, RunTolerance: 2}.Analyze(b.root).(bt.PacingReport)
+		b.render(w, "pacing", "Pacing", pacingViews(report))
+	case "contents":
+		log.Print("contents")
+		b.render(w, "contents", "Contents", bt.ContentsAnalyzer{}.Analyze(b.root))
+	case "address":
+		log.Print("address")
+		b.SendAddress(w, r)
+	case "api":
+		log.Print("api")
+		b.SendAPI(w, r)
+	case "analysis":
+		log.Print("analysis")
+		b.SendAnalysis(w, r)
+	case "events":
+		b.SendEvents(w, r)
+	case "history":
+		log.Print("history")
+		b.SendHistory(w, r)
+	case "scenes":
+		log.Print("scenes")
+		b.SendScenes(w, r)
+	case "", "index.html":
+		b.render(w, "index", "", b.progress())
 	default:
-		// index.html
-		sb := strings.Builder{}
-		sb.WriteString(`<head></head><body><h1>`)
-		sb.WriteString(b.root.GetFirstSentence())
-		sb.WriteString(`</h1></br><a href="structure/">Display Structure</a></p>
-			<a href="chaptercharacters/">Display Characters By Chapter</a></p>
-			<a href="chaptermatches/add/names/here/">Display Specific Characters By Chapter</a></p>
-			<a href="chapter/1/">Chapter 1</a>
-			</body>
-			`)
-		w.Header().Set("Content-Type", "text/html; charset=utf-8")
-		_, err := w.Write([]byte(sb.String()))
-		if err != nil {
-			log.Fatalf("Error serving default: %v", err)
-		}
+		http.NotFound(w, r)
 	}
 }
 
-func (b BooktoolsServer) SendStructure(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Content-Type", "text/html; charset=utf-8")
-	sb := strings.Builder{}
-	sb.WriteString("<head><link rel=\"stylesheet\" href=\"/booktools.css\"><h1>")
-	sb.WriteString(b.root.GetFirstSentence())
-	sb.WriteString("</h1></head><body>")
-
-	iter := bt.NewChunkIterator(b.root)
-
-	for iter.NextChunk() != nil {
-		if iter.Value().Unit > 0 {
-			sb.WriteString(fmt.Sprintf("<p style=\"margin-left: %dpx\">", iter.GetDepth()*50))
-			for i := 0; i < iter.GetDepth(); i++ {
-				sb.WriteString("    ")
-			}
-			sb.WriteString("[" + bt.UnitToString(iter.Value().Unit) + "]")
-			if iter.Value().Unit == bt.Sentence {
-				sb.WriteString(iter.Value().String())
-			}
-			sb.WriteString("</p>\n")
-		}
+// render executes the named page template inside the shared layout.
+func (b BooktoolsServer) render(w http.ResponseWriter, name string, heading string, data interface{}) {
+	title := strings.TrimSpace(b.root.GetFirstSentence())
+	if heading == "" {
+		heading = title
 	}
-	sb.WriteString("</body>")
-	_, err := w.Write([]byte(sb.String()))
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	err := b.theme.pages[name].ExecuteTemplate(w, "layout", page{Title: title, Heading: heading, Data: data, Live: b.live != nil})
 	if err != nil {
-		log.Fatalf("Error serving structure: %v", err)
+		log.Printf("Error serving %v: %v", name, err)
+	}
+}
+
+func (b BooktoolsServer) SendStructure(w http.ResponseWriter, r *http.Request) {
+	lines := make([]structureLine, 0)
+	for _, n := range b.root.Structure(true, 99) {
+		lines = append(lines, structureLine{Indent: n.Depth * 50, Unit: n.Unit, Text: n.Text})
 	}
+	b.render(w, "structure", "", lines)
 }
 
 func (b BooktoolsServer) SendChapter(w http.ResponseWriter, r *http.Request) {
@@ -98,169 +203,242 @@ func (b BooktoolsServer) SendChapter(w http.ResponseWriter, r *http.Request) {
 	target := strings.ToLower(path.Base(p))
 	i, err := strconv.Atoi(target)
 	if err != nil {
-		w.Header().Set("Content-Type", "text/html; charset=utf-8")
-		w.WriteHeader(http.StatusNotFound)
-		w.Write([]byte(fmt.Sprintf("<body>Could not parse target %v.</body>", target)))
+		http.Error(w, fmt.Sprintf("Could not parse target %v.", target), http.StatusNotFound)
+		return
+	}
+	chapter := b.root.GetChapterChunk(i)
+	if chapter == nil {
+		http.Error(w, fmt.Sprintf("There is no chapter %d.", i), http.StatusNotFound)
+		return
 	}
 
-	w.Header().Set("Content-Type", "text/html; charset=utf-8")
-	sb := strings.Builder{}
-	sb.WriteString("<head><link rel=\"stylesheet\" href=\"/booktools.css\"><h1>")
-	sb.WriteString(b.root.GetFirstSentence())
-	sb.WriteString("</h1></head><body>")
-	sb.WriteString(b.root.GetChapterHTML(i))
-	sb.WriteString("</body>")
-	_, err = w.Write([]byte(sb.String()))
-	if err != nil {
-		log.Fatalf("Error serving structure: %v", err)
+	chapters := b.root.Chapters()
+	view := chapterView{
+		Chapter:  i,
+		Title:    strings.TrimSpace(chapter.GetFirstSentence()),
+		Chapters: len(chapters),
+		Toggles:  append([]string{"echo", "opener"}, bt.StyleKinds...),
+	}
+	if i > 1 {
+		view.Prev = i - 1
+	}
+	if i < len(chapters) {
+		view.Next = i + 1
+	}
+	total, before := 0, 0
+	for j, c := range chapters {
+		wc := c.GetWordCount()
+		if j < i-1 {
+			before = before + wc
+		}
+		total = total + wc
+	}
+	if total > 0 {
+		view.StartPercent = before * 100 / total
+		view.EndPercent = (before + chapter.GetWordCount()) * 100 / total
+	}
+	h := b.chapterHighlights(i)
+	for j, section := range chapter.Children {
+		sa := bt.Address{Chapter: i, Section: j + 1}
+		sv := sectionView{Address: sa.String()}
+		fields := make([]string, 0, len(section.Meta))
+		for field := range section.Meta {
+			fields = append(fields, field)
+		}
+		sort.Strings(fields)
+		for _, field := range fields {
+			value := section.Meta[field]
+			sv.Meta = append(sv.Meta, metaView{Field: field, Value: value, Href: scenesHref(nil, field)})
+		}
+		for k, paragraph := range section.Children {
+			pa := sa
+			pa.Paragraph = k + 1
+			sv.Paragraphs = append(sv.Paragraphs, paragraphView{Address: pa.String(), Words: h.words(paragraph)})
+		}
+		view.Sections = append(view.Sections, sv)
 	}
+	b.render(w, "chapter", view.Title, view)
 }
 
-func (b BooktoolsServer) SendChapterCharacters(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Content-Type", "text/html; charset=utf-8")
-	sb := strings.Builder{}
-	sb.WriteString("<head><link rel=\"stylesheet\" href=\"/booktools.css\"><h1>")
-	sb.WriteString(b.root.GetFirstSentence())
-	sb.WriteString("</h1></head><body><table class=\"simpleTable\">\n")
-	sb.WriteString("<tr><td>Chapter</td><td>WordCount</td><td>Characters</td><td>First Sentence</td></tr>\n")
-	iter := bt.NewChunkIterator(b.root)
-
-	i := 0
-	for iter.NextChunk() != nil {
-		if iter.Value().Unit == bt.Chapter {
-			i = i + 1
-			sb.WriteString("<tr>")
-			wc := iter.Value().GetWordCount()
-			chars := ""
-			charFreq := bt.CharacterFrequencies(iter.Value(), 1, 0)
-			pl := bt.RankByFrequency(charFreq)
-			for i, p := range pl {
-				if i < 8 {
-					chars = chars + p.Key + ", "
-				}
-			}
-			chars = strings.TrimSuffix(chars, ", ")
-			sb.WriteString(fmt.Sprintf("<td><a href=\"/chapter/%d\">Chapter %d</a></td>", i, i))
-			sb.WriteString(fmt.Sprintf("<td>%d</td><td>%v</td><td>%v</td>\n", wc, chars, iter.Value().GetFirstSentence()))
-			sb.WriteString("</tr>\n")
+// SendSearch shows the search form, and the results of a search when
+// given a query in q.
+func (b BooktoolsServer) SendSearch(w http.ResponseWriter, r *http.Request) {
+	view := searchView{Query: r.URL.Query().Get("q"), Options: searchOptions(r), Boolean: r.URL.Query().Get("boolean") != ""}
+	if view.Query != "" && view.Boolean {
+		q, err := bt.ParseQuery(view.Query, view.Options)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			view.Error = err.Error()
+		} else {
+			view.Hits = q.Find(b.root)
+		}
+	} else if view.Query != "" {
+		results, err := bt.Search(b.root, view.Query, view.Options)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			view.Error = err.Error()
 		}
+		view.Results = results
 	}
-	sb.WriteString("</table></body>\n")
-	_, err := w.Write([]byte(sb.String()))
+	b.render(w, "search", "Search", view)
+}
+
+// searchOptions reads the regexp, case, diacritics, stem and context
+// parameters of a search request.
+func searchOptions(r *http.Request) bt.SearchOptions {
+	q := r.URL.Query()
+	opts := bt.SearchOptions{
+		Regexp:          q.Get("regexp") != "",
+		MatchCase:       q.Get("case") != "",
+		MatchDiacritics: q.Get("diacritics") != "",
+		Stem:            q.Get("stem") != "",
+		Context:         6,
+	}
+	if n, err := strconv.Atoi(q.Get("context")); err == nil && n >= 0 {
+		opts.Context = n
+	}
+	return opts
+}
+
+// SendAddress redirects /address/3.2.5 to the anchor for that section or
+// paragraph in its chapter.
+func (b BooktoolsServer) SendAddress(w http.ResponseWriter, r *http.Request) {
+	a, err := bt.ParseAddress(path.Base(path.Clean(r.URL.Path)))
 	if err != nil {
-		log.Fatalf("Error serving chapter characters: %v", err)
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if b.root.Lookup(a) == nil {
+		http.Error(w, fmt.Sprintf("There is nothing at %v.", a), http.StatusNotFound)
+		return
+	}
+	target := fmt.Sprintf("/chapter/%d/", a.Chapter)
+	switch {
+	case a.Paragraph > 0:
+		target = target + "#p" + bt.Address{Chapter: a.Chapter, Section: a.Section, Paragraph: a.Paragraph}.String()
+	case a.Section > 0:
+		target = target + "#s" + a.String()
 	}
+	http.Redirect(w, r, target, http.StatusFound)
+}
+
+func (b BooktoolsServer) SendChapterCharacters(w http.ResponseWriter, r *http.Request) {
+	a := bt.ChapterCharacterAnalyzer{TopX: 8, IncludeSentences: true, IncludeXthSentence: -1, WordCount: true}
+	chapters := b.root.Chapters()
+	rows := make([]chapterCharactersRow, 0)
+	for i, s := range a.Analyze(b.root).(*bt.ChapterCharacterResult).Chapters {
+		rows = append(rows, chapterCharactersRow{ChapterSummary: s, Readability: chapters[i].Readability()})
+	}
+	b.render(w, "chaptercharacters", "", rows)
 }
 
 func (b BooktoolsServer) SendChapterMatches(w http.ResponseWriter, r *http.Request) {
 	p := path.Clean(r.URL.Path)
 	elements := strings.Split(strings.TrimPrefix(p, "/"), "/")
 	if elements == nil || len(elements) < 2 {
-		w.WriteHeader(http.StatusNotFound)
-		fmt.Fprint(w, "404 Page Not Found")
+		http.NotFound(w, r)
 		return
 	}
-	elements = elements[1:]
-	w.Header().Set("Content-Type", "text/html; charset=utf-8")
-	sb := strings.Builder{}
-	sb.WriteString("<head><link rel=\"stylesheet\" href=\"/booktools.css\"><h1>")
-	sb.WriteString(b.root.GetFirstSentence())
-	sb.WriteString("</h1></head><body><table class=\"simpleTable\">\n")
-	sb.WriteString("<tr><td>Chapter</td>")
-	for _, element := range elements {
-		sb.WriteString("<td>")
-		sb.WriteString(element)
-		sb.WriteString("</td>")
+	view := matchesView{Names: elements[1:]}
+	queries := make([]*bt.Query, len(view.Names))
+	for i, element := range view.Names {
+		q, err := bt.ParseQuery(element, bt.SearchOptions{})
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Could not parse %v: %v", element, err), http.StatusBadRequest)
+			return
+		}
+		queries[i] = q
 	}
-	sb.WriteString("</tr>\n")
-	iter := bt.NewChunkIterator(b.root)
-
-	i := 0
-	for iter.NextChunk() != nil {
-		if iter.Value().Unit == bt.Chapter {
-			i = i + 1
-			sb.WriteString("<tr>")
-			sb.WriteString(fmt.Sprintf("<td><a href=\"/chapter/%d\">Chapter %d</a></td>", i, i))
-			for _, element := range elements {
-				wc := iter.Value().GetSpecificWordCount(element)
-				if wc > 0 {
-					sb.WriteString("<td>")
-					sb.WriteString(strconv.Itoa(wc))
-					sb.WriteString(" - " + string([]rune(element)[0:10]))
-				} else {
-					sb.WriteString("<td style=\"background-color: ffffff;\" class=\"empty\">")
-				}
-				sb.WriteString("</td>")
+	for i, c := range b.root.Chapters() {
+		row := matchRow{Chapter: i + 1}
+		for j, element := range view.Names {
+			// A plain name counts its exact appearances, while an
+			// expression such as "Anna AND knife" counts the
+			// paragraphs which match it.
+			count := 0
+			if queries[j].IsSimple() {
+				count = c.GetSpecificWordCount(element)
+			} else {
+				count = queries[j].Count(c)
 			}
-			sb.WriteString("</tr>\n")
+			row.Cells = append(row.Cells, matchCell{Name: element, Count: count})
 		}
+		view.Chapters = append(view.Chapters, row)
 	}
-	sb.WriteString("</table></body>\n")
-	_, err := w.Write([]byte(sb.String()))
-	if err != nil {
-		log.Fatalf("Error serving chapter matches: %v", err)
-	}
+	b.render(w, "chaptermatches", "", view)
 }
 
-func SendCSS(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Content-Type", "text/html; charset=utf-8")
-	_, err := w.Write([]byte(`
-empty td {
-	border: none;
-	background-color: #FFFFFF;
-}
-table.simpleTable {
-  border: 1px solid #1C6EA4;
-  background-color: #DCEEDB;
-  width: 100%;
-  text-align: left;
-  empty-cells: hide;
-}
-table.simpleTable td, table.simpleTable th {
-  border: 1px solid #AAAAAA;
-  padding: 3px 2px;
-}
-table.simpleTable tbody td {
-  font-size: 13px;
-}
-table.simpleTable tr:nth-child(even) {
-  background: #B8F5C5;
-}
-table.simpleTable thead {
-  background: #14A44D;
-  border-bottom: 2px solid #444444;
-}
-table.simpleTable thead th {
-  font-size: 15px;
-  font-weight: bold;
-  color: #FFFFFF;
-  border-left: 2px solid #D0E4F5;
-}
-table.simpleTable thead th:first-child {
-  border-left: none;
+// SendAnalysis lists the registered analyzers at /analysis/, and shows
+// the result of one at /analysis/name/.
+func (b BooktoolsServer) SendAnalysis(w http.ResponseWriter, r *http.Request) {
+	p := path.Clean(r.URL.Path)
+	elements := strings.Split(strings.TrimPrefix(p, "/"), "/")
+	if len(elements) < 2 {
+		b.render(w, "analyzers", "Analyses", bt.Analyzers())
+		return
+	}
+	a := bt.GetAnalyzer(elements[1])
+	if a == nil {
+		http.NotFound(w, r)
+		return
+	}
+	if len(elements) > 2 && elements[2] == "json" {
+		http.Redirect(w, r, "/api/v1/analysis/"+a.Name(), http.StatusMovedPermanently)
+		return
+	}
+	result := a.Analyze(b.root)
+	b.render(w, "analysis", a.Description(), analysisView{Name: a.Name(), Columns: result.Columns(), Rows: result.Rows()})
 }
 
-table.simpleTable tfoot td {
-  font-size: 14px;
-}
-table.simpleTable tfoot .links {
-  text-align: right;
-}
-table.simpleTable tfoot .links a{
-  display: inline-block;
-  background: #1C6EA4;
-  color: #FFFFFF;
-  padding: 2px 8px;
-  border-radius: 5px;
-}`))
+// SendCSS serves the theme's stylesheet.
+func (b BooktoolsServer) SendCSS(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/css; charset=utf-8")
+	_, err := w.Write(b.theme.css)
 	if err != nil {
-		log.Fatalf("Error serving CSS: %v", err)
+		log.Printf("Error serving CSS: %v", err)
 	}
+}
 
+// Listen serves root on listenPort, using the templates and stylesheet
+// in themeDir in place of the built-in ones, where present.
+func Listen(root *bt.Chunk, listenPort int, themeDir string) {
+	Serve(root, Options{Port: listenPort, ThemeDir: themeDir})
 }
 
-func Listen(root *bt.Chunk, listenPort int) {
-	listenOn := fmt.Sprintf(":%d", listenPort)
-	http.Handle("/", BooktoolsServer{root: root})
+// Options configures Serve.
+type Options struct {
+	Port int
+	// ThemeDir holds templates and a stylesheet to use in place of the
+	// built-in ones.
+	ThemeDir string
+	// Updates, where set, delivers trees to serve in place of root as
+	// the manuscript changes, and open pages reload.
+	Updates <-chan *bt.Chunk
+	// History, where set, is the manuscript's git history, charted at
+	// /history/.
+	History *bt.History
+	// Project holds the targets shown on the index page, and
+	// ProgressLog the log of word counts to measure progress by.
+	Project     *bt.Project
+	ProgressLog string
+}
+
+// Serve serves root as configured by o.
+func Serve(root *bt.Chunk, o Options) {
+	theme, err := LoadTheme(o.ThemeDir)
+	if err != nil {
+		log.Fatalf("Error loading theme: %v", err)
+	}
+	b := BooktoolsServer{root: root, theme: theme, echoes: &echoCache{}, history: o.History, project: o.Project, progressLog: o.ProgressLog}
+	if o.Updates != nil {
+		b.live = newLiveRoot(root)
+		go func() {
+			for root := range o.Updates {
+				b.live.swap(root)
+			}
+		}()
+	}
+	listenOn := fmt.Sprintf(":%d", o.Port)
+	http.Handle("/", b)
 	log.Fatal(http.ListenAndServe(listenOn, nil))
 }
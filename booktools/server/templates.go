@@ -0,0 +1,103 @@
+package server
+
+import (
+	"embed"
+	"fmt"
+	"html/template"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+//go:embed templates
+var embedded embed.FS
+
+// pages lists the templates rendered inside the shared layout.
+var pages = []string{
+	"index",
+	"structure",
+	"chapter",
+	"contents",
+	"search",
+	"chaptercharacters",
+	"readability",
+	"pacing",
+	"chaptermatches",
+	"analysis",
+	"analyzers",
+	"history",
+	"scenes",
+}
+
+var templateFuncs = template.FuncMap{
+	"join":   strings.Join,
+	"abbrev": abbrev,
+	"printf": fmt.Sprintf,
+	"sub":    func(a, b int) int { return a - b },
+}
+
+// Theme holds the parsed page templates and the stylesheet. Any file in
+// the theme directory replaces the embedded file of the same name.
+type Theme struct {
+	pages map[string]*template.Template
+	css   []byte
+}
+
+// LoadTheme parses the embedded templates, overriding them with those
+// found in dir, if dir is not empty.
+func LoadTheme(dir string) (*Theme, error) {
+	t := &Theme{pages: make(map[string]*template.Template)}
+
+	layout, err := parseTheme(template.New("layout").Funcs(templateFuncs), dir, "layout.html")
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range pages {
+		base, err := layout.Clone()
+		if err != nil {
+			return nil, err
+		}
+		t.pages[name], err = parseTheme(base, dir, name+".html")
+		if err != nil {
+			return nil, err
+		}
+	}
+	t.css, err = readTheme(dir, "booktools.css")
+	if err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+func parseTheme(t *template.Template, dir string, name string) (*template.Template, error) {
+	b, err := readTheme(dir, name)
+	if err != nil {
+		return nil, err
+	}
+	return t.Parse(string(b))
+}
+
+// readTheme reads name from dir if it exists there, and otherwise from
+// the embedded templates.
+func readTheme(dir string, name string) ([]byte, error) {
+	if dir != "" {
+		b, err := os.ReadFile(filepath.Join(dir, name))
+		if err == nil {
+			return b, nil
+		}
+		if !os.IsNotExist(err) {
+			return nil, err
+		}
+	}
+	return fs.ReadFile(embedded, "templates/"+name)
+}
+
+// abbrev shortens s to at most n runes.
+func abbrev(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[0:n])
+}
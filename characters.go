@@ -0,0 +1,210 @@
+package booktools
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+func init() {
+	RegisterAnalyzer(CharacterAnalyzer{MinAppearance: 3, MinNonFirst: 1})
+	RegisterAnalyzer(CharacterFrequencyAnalyzer{MinAppearance: 3, MinNonFirst: 1})
+	RegisterAnalyzer(ChapterCharacterAnalyzer{TopX: 3, IncludeXthSentence: -1})
+}
+
+// CharacterList is the sorted list of characters found in a work.
+type CharacterList []string
+
+func (l CharacterList) Columns() []string { return []string{"Character"} }
+
+func (l CharacterList) Rows() [][]string {
+	rows := make([][]string, len(l))
+	for i, name := range l {
+		rows[i] = []string{name}
+	}
+	return rows
+}
+
+func (l CharacterList) String() string {
+	sb := strings.Builder{}
+	for _, name := range l {
+		sb.WriteString(name + "\n")
+	}
+	return sb.String()
+}
+
+// CharacterAnalyzer lists the characters, as found by IdentifyCharacters.
+type CharacterAnalyzer struct {
+	MinAppearance int
+	MinNonFirst   int
+}
+
+func (a CharacterAnalyzer) Name() string        { return "characters" }
+func (a CharacterAnalyzer) Description() string { return "Lists the characters in the book" }
+func (a CharacterAnalyzer) Unit() int           { return Work }
+
+func (a CharacterAnalyzer) Analyze(c *Chunk) Result {
+	l := CharacterList(IdentifyCharacters(c, a.MinAppearance, a.MinNonFirst))
+	sort.Strings(l)
+	return l
+}
+
+// CharacterFrequency is the number of times a character is named.
+type CharacterFrequency struct {
+	Name  string `json:"name" yaml:"name"`
+	Count int    `json:"count" yaml:"count"`
+}
+
+// FrequencyList is a list of characters and their frequencies, sorted
+// by name.
+type FrequencyList []CharacterFrequency
+
+// NewFrequencyList sorts the frequencies in nf by name.
+func NewFrequencyList(nf map[string]int) FrequencyList {
+	l := make(FrequencyList, 0, len(nf))
+	for k, v := range nf {
+		l = append(l, CharacterFrequency{Name: k, Count: v})
+	}
+	sort.Slice(l, func(i, j int) bool {
+		return l[i].Name < l[j].Name
+	})
+	return l
+}
+
+func (l FrequencyList) Columns() []string { return []string{"Character", "Count"} }
+
+func (l FrequencyList) Rows() [][]string {
+	rows := make([][]string, len(l))
+	for i, f := range l {
+		rows[i] = []string{f.Name, strconv.Itoa(f.Count)}
+	}
+	return rows
+}
+
+func (l FrequencyList) String() string {
+	sb := strings.Builder{}
+	for _, f := range l {
+		sb.WriteString(fmt.Sprintf("%v: %-10d\n", f.Name, f.Count))
+	}
+	return sb.String()
+}
+
+// CharacterFrequencyAnalyzer lists the characters along with the number
+// of times each appears, as found by CharacterFrequencies.
+type CharacterFrequencyAnalyzer struct {
+	MinAppearance int
+	MinNonFirst   int
+}
+
+func (a CharacterFrequencyAnalyzer) Name() string { return "characterFrequencies" }
+func (a CharacterFrequencyAnalyzer) Description() string {
+	return "Lists the characters and the frequency with which they appear."
+}
+func (a CharacterFrequencyAnalyzer) Unit() int { return Work }
+
+func (a CharacterFrequencyAnalyzer) Analyze(c *Chunk) Result {
+	return NewFrequencyList(CharacterFrequencies(c, a.MinAppearance, a.MinNonFirst))
+}
+
+// ChapterSummary describes the most frequent characters in one chapter.
+type ChapterSummary struct {
+	Chapter       int      `json:"chapter" yaml:"chapter"`
+	WordCount     int      `json:"wordCount,omitempty" yaml:"wordCount,omitempty"`
+	FirstSentence string   `json:"firstSentence,omitempty" yaml:"firstSentence,omitempty"`
+	XthSentence   string   `json:"xthSentence,omitempty" yaml:"xthSentence,omitempty"`
+	Characters    []string `json:"characters" yaml:"characters"`
+}
+
+// ChapterCharacterResult holds a ChapterSummary for every chapter.
+type ChapterCharacterResult struct {
+	Chapters []ChapterSummary `json:"chapters" yaml:"chapters"`
+
+	wordCount bool
+}
+
+func (r *ChapterCharacterResult) Columns() []string {
+	if r.wordCount {
+		return []string{"Chapter", "WordCount", "Sentences", "Characters"}
+	}
+	return []string{"Chapter", "Sentences", "Characters"}
+}
+
+func (r *ChapterCharacterResult) Rows() [][]string {
+	rows := make([][]string, len(r.Chapters))
+	for i, s := range r.Chapters {
+		row := []string{fmt.Sprintf("%03d", s.Chapter)}
+		if r.wordCount {
+			row = append(row, fmt.Sprintf("%03d", s.WordCount))
+		}
+		rows[i] = append(row, s.sentences(), strings.Join(s.Characters, ","))
+	}
+	return rows
+}
+
+func (r *ChapterCharacterResult) String() string {
+	sb := strings.Builder{}
+	for _, s := range r.Chapters {
+		chars := strings.Join(s.Characters, ",")
+		if r.wordCount {
+			sb.WriteString(fmt.Sprintf("%03d: [%03d] %v%v\n", s.Chapter, s.WordCount, s.sentences(), chars))
+		} else {
+			sb.WriteString(fmt.Sprintf("%03d: %v%v\n", s.Chapter, s.sentences(), chars))
+		}
+	}
+	return sb.String()
+}
+
+func (s ChapterSummary) sentences() string {
+	sent := ""
+	if s.FirstSentence != "" {
+		sent = "[" + s.FirstSentence + "] "
+	}
+	if s.XthSentence != "" {
+		sent = sent + " " + "[" + s.XthSentence + "] "
+	}
+	return sent
+}
+
+// ChapterCharacterAnalyzer lists the TopX characters in each chapter,
+// optionally with the chapter's word count and first or Xth sentence.
+type ChapterCharacterAnalyzer struct {
+	TopX               int
+	IncludeSentences   bool
+	IncludeXthSentence int
+	WordCount          bool
+}
+
+func (a ChapterCharacterAnalyzer) Name() string        { return "chapterCharacters" }
+func (a ChapterCharacterAnalyzer) Description() string { return "Lists the characters in each chapter" }
+func (a ChapterCharacterAnalyzer) Unit() int           { return Chapter }
+
+func (a ChapterCharacterAnalyzer) Analyze(c *Chunk) Result {
+	r := &ChapterCharacterResult{Chapters: make([]ChapterSummary, 0), wordCount: a.WordCount}
+	iter := NewChunkIterator(c)
+	i := 0
+	for iter.NextChunk() != nil {
+		if iter.Value().Unit != Chapter {
+			continue
+		}
+		i = i + 1
+		s := ChapterSummary{Chapter: i, Characters: make([]string, 0, a.TopX)}
+		if a.IncludeSentences {
+			s.FirstSentence = iter.Value().GetFirstSentence()
+		}
+		if a.IncludeXthSentence > -1 {
+			s.XthSentence = iter.Value().GetNthSentence(a.IncludeXthSentence)
+		}
+		if a.WordCount {
+			s.WordCount = iter.Value().GetWordCount()
+		}
+		pl := RankByFrequency(CharacterFrequencies(iter.Value(), 1, 0))
+		for j, p := range pl {
+			if j < a.TopX {
+				s.Characters = append(s.Characters, p.Key)
+			}
+		}
+		r.Chapters = append(r.Chapters, s)
+	}
+	return r
+}
@@ -3,6 +3,9 @@ package booktools
 import (
 	"bytes"
 	"io"
+	"strings"
+	"unicode"
+	"unicode/utf8"
 )
 
 const (
@@ -32,12 +35,26 @@ func UnitToString(unit int) string {
 	return "Unknown"
 }
 
+// StringToUnit is the inverse of UnitToString, ignoring case. It
+// returns -1 if s names no unit.
+func StringToUnit(s string) int {
+	for unit := Word; unit <= Work; unit++ {
+		if strings.EqualFold(s, UnitToString(unit)) {
+			return unit
+		}
+	}
+	return -1
+}
+
 type Chunk struct {
 	Position int64
 	Length   int64
 	Unit     int
 	Word     string
 	Children []*Chunk
+	// Meta is the metadata of a Section, read from the block at its
+	// start. See Chunker.
+	Meta map[string]string
 }
 
 type Chunker struct {
@@ -56,6 +73,24 @@ type Chunker struct {
 	out chan *Chunk
 	b   bytes.Buffer
 
+	// meta is the metadata of the current section. metaAllowed is
+	// true until the section has words, and afterMarker just after a
+	// section marker line. A block which may be metadata is held back
+	// in held, from heldAt, until its closing fence shows what it is.
+	// A comment is held back too, with no fence, until its end shows
+	// whether it is a suppression comment, to be dropped along with the
+	// line break after it where dropLineEnd is set, or otherwise may
+	// open a metadata block where heldMeta is set.
+	meta          map[string]string
+	metaAllowed   bool
+	afterMarker   bool
+	held          []heldRune
+	heldAt        int64
+	heldFence     string
+	heldMeta      bool
+	heldLineStart bool
+	dropLineEnd   bool
+
 	OnSentence       func(c *Chunker, s string)
 	OnBeforeSentence func(c *Chunker, s string)
 }
@@ -71,19 +106,27 @@ func NewChunker(r io.Reader, out chan *Chunk) *Chunker {
 		lastParagraph: 0,
 		lastChapter:   0,
 		lastRune:      '|',
+		metaAllowed:   true,
 
 		out: out,
 	}
 }
 
+// heldRune is a rune of a block held back by the Chunker.
+type heldRune struct {
+	r    rune
+	size int
+}
+
 func (c *Chunker) Read(p []byte) (n int, err error) {
 	n, err = c.r.Read(p)
 	if !(err == nil) {
+		c.closeHeld()
 		close(c.out)
 		//fmt.Println(err)
 		return 0, err
 	}
-	c.b.Write(p)
+	c.b.Write(p[:n])
 	c.process()
 	return n, nil
 }
@@ -94,6 +137,7 @@ func (c *Chunker) Word() {
 	}
 	c.out <- &Chunk{Position: c.lastWord, Length: c.position - c.lastWord, Unit: Word, Word: c.curWord}
 	c.lastWord = c.position
+	c.metaAllowed = false
 }
 
 func (c *Chunker) Sentence() {
@@ -126,8 +170,10 @@ func (c *Chunker) Section() {
 		return
 	}
 	//c.Paragraph()
-	c.out <- &Chunk{Position: c.lastSection, Length: c.position - c.lastSection, Unit: Section}
+	c.out <- &Chunk{Position: c.lastSection, Length: c.position - c.lastSection, Unit: Section, Meta: c.meta}
 	c.lastSection = c.position
+	c.meta = nil
+	c.metaAllowed = true
 }
 
 func (c *Chunker) Chapter() {
@@ -142,8 +188,11 @@ func (c *Chunker) Chapter() {
 }
 
 func (c *Chunker) process() {
-	var err error
-	for err == nil {
+	for {
+		if !utf8.FullRune(c.b.Bytes()) && c.b.Len() > 0 {
+			// Wait for the rest of a rune split between reads
+			return
+		}
 		r, size, err := c.b.ReadRune()
 		if !(err == nil) {
 			//fmt.Printf("Error, size: %v, %v\n", err, size)
@@ -151,38 +200,197 @@ func (c *Chunker) process() {
 			//c.chapter()
 			return
 		}
+		c.next(r, size)
+	}
+}
+
+// next takes the next rune of the text, holding back a block which may
+// be metadata.
+//
+// The metadata of a section is written as YAML, either as front matter
+// on the lines after the "---" marker which starts the section, closed
+// by another "---" line, or in a comment block, between lines "<!--"
+// and "-->", at the start of the section or after its chapter heading:
+//
+//	---
+//	pov: Anna
+//	tags: [flashback, rain]
+//	---
+//
+// A block which is not a mapping of names to values is text after all.
+//
+// Suppression comments for lint, such as
+// <!-- booktools-disable TYPO001 -->, are not text either, and are
+// dropped wherever they start a word.
+func (c *Chunker) next(r rune, size int) {
+	if c.held != nil {
 		c.position += int64(size)
+		c.held = append(c.held, heldRune{r, size})
+		if c.heldFence == "" {
+			c.checkComment(false)
+		} else if r == '\n' {
+			c.checkHeld(false)
+		}
+		return
+	}
+	if c.dropLineEnd {
 		switch r {
-		case ' ', '\r', '\n':
-//			if c.lastRune != '#' {
-				switch c.curWord {
-				case "---":
-					// Section marker
-					c.Section()
-				case "# Part":
-					// Chapter marker
-					c.Chapter()
-				default:
-					switch c.lastRune {
-					case '.', '!', '?':
-						//fmt.Printf("Sentence: [%v] %v\n", c.curWord, r)
-						c.Sentence()
-					case '\r', '\n':
-						//fmt.Println("Para")
-						c.Paragraph()
-					default:
-						if c.curWord != "" {
-							c.curSentence = c.curSentence + " " + c.curWord
-							c.Word()
-						}
-					}
-					//fmt.Printf("|%v| [%v][%v]\n", c.curWord, c.lastRune, r)
-				}
-				c.curWord = ""
-			//}
+		case ' ', '\t', '\r', '\n':
+			c.dropLineEnd = r != '\n'
+			c.position += int64(size)
+			return
+		}
+		c.dropLineEnd = false
+	}
+	atLineStart := c.position == 0 || c.lastRune == '\n' || c.lastRune == '\r'
+	opensBlock := false
+	if c.afterMarker {
+		if r == '\n' && c.lastRune == '\r' {
+			c.step(r, size)
+			return
+		}
+		c.afterMarker = false
+		opensBlock = !unicode.IsSpace(r)
+	} else {
+		opensBlock = r == '<' && atLineStart
+	}
+	opensBlock = opensBlock && c.metaAllowed && c.curWord == "" && c.curSentence == "" && c.lastSentence == c.lastParagraph
+	if opensBlock || (r == '<' && c.curWord == "") {
+		c.heldAt = c.position
+		c.heldFence = "---"
+		if r == '<' {
+			c.heldFence = ""
+			c.heldMeta = opensBlock
+			c.heldLineStart = atLineStart
+		}
+		c.position += int64(size)
+		c.held = []heldRune{{r, size}}
+		return
+	}
+	c.step(r, size)
+}
+
+// heldText is the text of the held block.
+func (c *Chunker) heldText() string {
+	sb := strings.Builder{}
+	for _, h := range c.held {
+		sb.WriteRune(h.r)
+	}
+	return sb.String()
+}
+
+// checkComment looks at a held comment after each rune. A suppression
+// comment is dropped, along with the line break after it where it
+// stands on a line of its own, and a line "<!--" where metadata may
+// start is held on as a metadata block. Anything else is text.
+func (c *Chunker) checkComment(atEnd bool) {
+	text := c.heldText()
+	switch {
+	case strings.HasSuffix(text, "-->"):
+		if loc := suppression.FindStringIndex(text); loc != nil && loc[0] == 0 && loc[1] == len(text) {
+			c.held = nil
+			c.dropLineEnd = c.heldLineStart
+			return
+		}
+	case atEnd:
+	case strings.HasSuffix(text, "\n"):
+		if c.heldMeta && strings.TrimSpace(text) == "<!--" {
+			c.heldFence = "-->"
+			return
+		}
+	case strings.HasPrefix(text, "<!--") || strings.HasPrefix("<!--", text):
+		return
+	}
+	c.release()
+}
+
+// checkHeld looks at the last line of the held block: a closing fence
+// ends it, and a blank line, or a comment not opened by a line "<!--",
+// shows it is text. At the end of the text, an unclosed block is text.
+func (c *Chunker) checkHeld(atEnd bool) {
+	text := c.heldText()
+	lines := strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+	last := strings.TrimSpace(lines[len(lines)-1])
+	switch {
+	case len(lines) > 1 && last == c.heldFence:
+		body := strings.Join(lines[:len(lines)-1], "\n")
+		if c.heldFence == "-->" {
+			body = strings.Join(lines[1:len(lines)-1], "\n")
+		}
+		if meta, ok := parseMeta(body); ok {
+			if c.meta == nil {
+				c.meta = make(map[string]string)
+			}
+			for k, v := range meta {
+				c.meta[k] = v
+			}
+			c.lastWord, c.lastSentence, c.lastParagraph = c.position, c.position, c.position
+			c.lastRune = c.held[len(c.held)-1].r
+			c.held = nil
+			return
+		}
+	case atEnd:
+	case last == "":
+	case c.heldFence == "-->" && strings.TrimSpace(lines[0]) != "<!--":
+	default:
+		return
+	}
+	c.release()
+}
+
+// closeHeld ends a block still held at the end of the text.
+func (c *Chunker) closeHeld() {
+	switch {
+	case c.held == nil:
+	case c.heldFence == "":
+		c.checkComment(true)
+	default:
+		c.checkHeld(true)
+	}
+}
+
+// release chunks the held block as text.
+func (c *Chunker) release() {
+	held := c.held
+	c.held = nil
+	c.position = c.heldAt
+	for _, h := range held {
+		c.step(h.r, h.size)
+	}
+}
+
+// step chunks one rune of text.
+func (c *Chunker) step(r rune, size int) {
+	c.position += int64(size)
+	switch r {
+	case ' ', '\r', '\n':
+		switch c.curWord {
+		case "---":
+			// Section marker
+			c.Section()
+			c.afterMarker = r != ' '
+		case "# Part":
+			// Chapter marker
+			c.Chapter()
 		default:
-			c.curWord = c.curWord + string(r)
+			switch c.lastRune {
+			case '.', '!', '?':
+				//fmt.Printf("Sentence: [%v] %v\n", c.curWord, r)
+				c.Sentence()
+			case '\r', '\n':
+				//fmt.Println("Para")
+				c.Paragraph()
+			default:
+				if c.curWord != "" {
+					c.curSentence = c.curSentence + " " + c.curWord
+					c.Word()
+				}
+			}
+			//fmt.Printf("|%v| [%v][%v]\n", c.curWord, c.lastRune, r)
 		}
-		c.lastRune = r
+		c.curWord = ""
+	default:
+		c.curWord = c.curWord + string(r)
 	}
+	c.lastRune = r
 }
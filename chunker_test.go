@@ -0,0 +1,130 @@
+package booktools
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestChunkerMeta(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		// meta is the metadata of each section, or nil where none
+		// should have any.
+		meta  []map[string]string
+		words int
+	}{
+		{
+			"front matter",
+			"Chapter 1\n\nAnna slept.\n\n---\npov: Ben\nlocation: Paris\n---\n\nBen woke.\n",
+			[]map[string]string{nil, {"pov": "Ben", "location": "Paris"}},
+			6,
+		},
+		{
+			"front matter list",
+			"Chapter 1\n\nAnna slept.\n\n---\ntags:\n  - rain\n  - night\n---\n\nBen woke.\n",
+			[]map[string]string{nil, {"tags": "rain, night"}},
+			6,
+		},
+		{
+			"comment block",
+			"Chapter 1\n\n<!--\npov: Anna\nstatus: draft\n-->\n\nAnna slept.\n",
+			[]map[string]string{{"pov": "Anna", "status": "draft"}},
+			4,
+		},
+		{
+			"comment block after marker",
+			"Chapter 1\n\nAnna slept.\n\n---\n\n<!--\npov: Ben\n-->\n\nBen woke.\n",
+			[]map[string]string{nil, {"pov": "Ben"}},
+			6,
+		},
+		{
+			"prose between markers",
+			"Chapter 1\n\nAnna slept.\n\n---\nThe note said: come home\n---\n\nBen woke.\n",
+			nil,
+			11,
+		},
+		{
+			"prose after marker",
+			"Chapter 1\n\nAnna slept.\n\n---\nBen woke.\n",
+			nil,
+			6,
+		},
+		{
+			"unclosed front matter",
+			"Chapter 1\n\nAnna slept.\n\n---\npov: Ben\n",
+			nil,
+			6,
+		},
+		{
+			"comment not on its own line",
+			"Chapter 1\n\n<!-- pov: Anna -->\n\nAnna slept.\n",
+			nil,
+			8,
+		},
+		{
+			"not a mapping",
+			"Chapter 1\n\n<!--\nA note to self\n-->\n\nAnna slept.\n",
+			nil,
+			10,
+		},
+	}
+	for _, test := range tests {
+		for ending, text := range lineEndings(test.text) {
+			t.Run(test.name+"/"+ending, func(t *testing.T) {
+				root := Parse(text, nil)
+				meta := make([]map[string]string, 0)
+				found := false
+				root.Walk(Section, func(a Address, c *Chunk) bool {
+					meta = append(meta, c.Meta)
+					found = found || c.Meta != nil
+					return true
+				})
+				if test.meta == nil && found || test.meta != nil && !reflect.DeepEqual(meta, test.meta) {
+					t.Errorf("meta %v, want %v", meta, test.meta)
+				}
+				if n := root.GetWordCount(); n != test.words {
+					t.Errorf("%d words, want %d", n, test.words)
+				}
+			})
+		}
+	}
+}
+
+func TestParseMeta(t *testing.T) {
+	tests := []struct {
+		text string
+		meta map[string]string
+		ok   bool
+	}{
+		{"pov: Anna", map[string]string{"pov": "Anna"}, true},
+		{"POV: Anna\nDate: 1921-04-03", map[string]string{"pov": "Anna", "date": "1921-04-03"}, true},
+		{"tags: [rain, night]", map[string]string{"tags": "rain, night"}, true},
+		{"tags:\n- rain\n- night", map[string]string{"tags": "rain, night"}, true},
+		{"status:", map[string]string{"status": ""}, true},
+		{"pov-character: Anna", map[string]string{"pov-character": "Anna"}, true},
+		{"The note said: come home", nil, false},
+		{"pov: Anna\nAnd then she left.", nil, false},
+		{"- rain", nil, false},
+		{"place:\n  city: Paris", nil, false},
+		{"", nil, false},
+	}
+	for _, test := range tests {
+		meta, ok := parseMeta(test.text)
+		if ok != test.ok || !reflect.DeepEqual(meta, test.meta) {
+			t.Errorf("parseMeta(%q) = %v, %v, want %v, %v", test.text, meta, ok, test.meta, test.ok)
+		}
+	}
+}
+
+func TestStringToUnit(t *testing.T) {
+	for unit := Word; unit <= Work; unit++ {
+		if got := StringToUnit(strings.ToLower(UnitToString(unit))); got != unit {
+			t.Errorf("StringToUnit(%q) = %d, want %d", UnitToString(unit), got, unit)
+		}
+	}
+	if got := StringToUnit("page"); got != -1 {
+		t.Errorf("StringToUnit(page) = %d, want -1", got)
+	}
+}
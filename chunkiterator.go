@@ -2,6 +2,7 @@ package booktools
 
 import (
 	"fmt"
+	"html"
 	"sort"
 	"strings"
 	"unicode"
@@ -141,7 +142,7 @@ func (c *Chunk) String() string {
 
 func (c *Chunk) HTML() string {
 	if c.Children == nil {
-		return c.Word
+		return html.EscapeString(c.Word)
 	}
 	iter := NewChunkIterator(c)
 	sb := strings.Builder{}
@@ -149,7 +150,7 @@ func (c *Chunk) HTML() string {
 	for iter.NextChunk() != nil {
 		switch iter.Value().Unit {
 		case Word:
-			sb.WriteString(iter.Value().Word + " ")
+			sb.WriteString(html.EscapeString(iter.Value().Word) + " ")
 		case Sentence:
 			sb.WriteString(" ")
 		case Paragraph:
@@ -168,107 +169,90 @@ func (c *Chunk) PrintStructure(includeSentences bool, maxDepth int) string {
 	if c.Children == nil {
 		return c.Word
 	}
+	return c.Structure(includeSentences, maxDepth).String()
+}
+
+// Structure lists the chunks above Word beneath c, down to maxDepth.
+func (c *Chunk) Structure(includeSentences bool, maxDepth int) StructureList {
+	l := make(StructureList, 0)
 	iter := NewChunkIterator(c)
-	sb := strings.Builder{}
 	for iter.NextChunk() != nil {
 		if iter.Value().Unit > 0 && iter.GetDepth() < maxDepth {
-			for i := 0; i < iter.GetDepth(); i++ {
-				sb.WriteString("    ")
-			}
-			sb.WriteString("[" + UnitToString(iter.Value().Unit) + "]")
+			n := StructureNode{Depth: iter.GetDepth(), Unit: UnitToString(iter.Value().Unit)}
 			if includeSentences && iter.Value().Unit == Sentence {
-				sb.WriteString(iter.Value().String())
+				n.Text = iter.Value().String()
 			}
-			sb.WriteString("\n")
+			l = append(l, n)
 		}
 	}
-	return sb.String()
+	return l
 }
 
+// IdentifyCharacters returns the names which appear more than minAppearance
+// times, and more than minNonFirst times other than as the first word
+// of a sentence.
 func IdentifyCharacters(root *Chunk, minAppearance int, minNonFirst int) []string {
-	var incidence = make(map[string]int)
-	var nonfirst = make(map[string]int)
 	var confirmed = make([]string, 0)
+	for k := range CharacterFrequencies(root, minAppearance, minNonFirst) {
+		confirmed = append(confirmed, k)
+	}
+	return confirmed
+}
 
-	name := ""
+// CharacterAliases maps the other names a character goes by to the one
+// they are counted under, and NotCharacters are capitalized words which
+// are never taken for characters. Both are empty unless a project sets
+// them.
+var CharacterAliases = map[string]string{}
+var NotCharacters = map[string]bool{}
 
-	//	fmt.Println("New iterator")
-	iter := NewChunkIterator(root)
-	for iter.NextWord() != nil {
-		k := iter.Value()
-		w := k.Word
-		w = strings.Replace(w, "\"", "", -1)
-		w = strings.TrimSuffix(w, "'ve")
-		w = strings.TrimSuffix(w, "'re")
-		w = strings.TrimSuffix(w, "'d")
-		w = strings.TrimSuffix(w, "'ll")
-		w = strings.TrimSuffix(w, "'s")
-		w = strings.Replace(w, "'", "", -1)
-		w = strings.Replace(w, ",", "", -1)
-		w = strings.Replace(w, "?", "", -1)
-		w = strings.Replace(w, "!", "", -1)
-		w = strings.Replace(w, ".", "", -1)
-		c, _ := utf8.DecodeRuneInString(w)
-		if unicode.IsUpper(c) {
-			//fmt.Printf("%v\n", k)
-			incidence[w] = incidence[w] + 1
-			if !iter.FirstWordInSentence {
-				nonfirst[w] = nonfirst[w] + 1
-			}
-			if name == "" {
-				name = w
-			} else {
-				name = name + " " + w
-			}
-			if name != k.Word {
-				incidence[name] = incidence[name] + 1
-			}
-		} else {
-			name = ""
+// CharacterFrequencies returns the same names as IdentifyCharacters
+// along with the number of times each appears.
+func CharacterFrequencies(root *Chunk, minAppearance int, minNonFirst int) map[string]int {
+	var confirmed = make(map[string]int)
+
+	incidence, nonfirst := nameIncidence(root)
+	for alias, name := range CharacterAliases {
+		if alias == name {
+			continue
 		}
-		//fmt.Printf("%v [%v]\n", w, name)
+		incidence[name] = incidence[name] + incidence[alias]
+		nonfirst[name] = nonfirst[name] + nonfirst[alias]
+		delete(incidence, alias)
+		delete(nonfirst, alias)
 	}
-
 	for k, v := range incidence {
+		if NotCharacters[k] {
+			continue
+		}
 		if v > minAppearance && nonfirst[k] > minNonFirst {
 			// If we have not seen a name at least X times
 			// It is probably not a significant character
 			// If it is only ever capitalized as the first
 			// word in a sentence, probably not a significant
 			// character
-			confirmed = append(confirmed, k)
+			confirmed[k] = v
 		}
 	}
 
 	return confirmed
 }
 
-func CharacterFrequencies(root *Chunk, minAppearance int, minNonFirst int) map[string]int {
+// nameIncidence counts every capitalized word, and every run of
+// consecutive capitalized words, beneath root. The second map counts
+// only the appearances which were not the first word of a sentence.
+func nameIncidence(root *Chunk) (map[string]int, map[string]int) {
 	var incidence = make(map[string]int)
 	var nonfirst = make(map[string]int)
-	var confirmed = make(map[string]int)
 
 	name := ""
 
-	//	fmt.Println("New iterator")
 	iter := NewChunkIterator(root)
 	for iter.NextWord() != nil {
 		k := iter.Value()
-		w := k.Word
-		w = strings.Replace(w, "\"", "", -1)
-		w = strings.TrimSuffix(w, "'ve")
-		w = strings.TrimSuffix(w, "'re")
-		w = strings.TrimSuffix(w, "'d")
-		w = strings.TrimSuffix(w, "'ll")
-		w = strings.TrimSuffix(w, "'s")
-		w = strings.Replace(w, "'", "", -1)
-		w = strings.Replace(w, ",", "", -1)
-		w = strings.Replace(w, "?", "", -1)
-		w = strings.Replace(w, "!", "", -1)
-		w = strings.Replace(w, ".", "", -1)
+		w := NormalizeWord(k.Word)
 		c, _ := utf8.DecodeRuneInString(w)
 		if unicode.IsUpper(c) {
-			//fmt.Printf("%v\n", k)
 			incidence[w] = incidence[w] + 1
 			if !iter.FirstWordInSentence {
 				nonfirst[w] = nonfirst[w] + 1
@@ -284,22 +268,8 @@ func CharacterFrequencies(root *Chunk, minAppearance int, minNonFirst int) map[s
 		} else {
 			name = ""
 		}
-		//fmt.Printf("%v [%v]\n", w, name)
-	}
-
-	for k, v := range incidence {
-		if v > minAppearance && nonfirst[k] > minNonFirst {
-			// If we have not seen a name at least X times
-			// It is probably not a significant character
-			// If it is only ever capitalized as the first
-			// word in a sentence, probably not a significant
-			// character
-			confirmed[k] = v
-			//			confirmed = append(confirmed, k)
-		}
 	}
-
-	return confirmed
+	return incidence, nonfirst
 }
 
 func (c *Chunk) GetWordCount() int {
@@ -395,50 +365,20 @@ func (c *Chunk) PrintTopXCharactersPerChapter(includeSentences bool, includeXthS
 	if c.Children == nil {
 		return
 	}
-	iter := NewChunkIterator(c)
-	i := 0
-	var sent string
-	var wc int
-	for iter.NextChunk() != nil {
-		if iter.Value().Unit == Chapter {
-			i = i + 1
-			sent = ""
-			wc = 0
-			if includeSentences {
-				sent = "[" + iter.Value().GetFirstSentence() + "] "
-			}
-			if includeXthSentence > -1 {
-				sent = sent + " " + "[" + iter.Value().GetNthSentence(includeXthSentence) + "] "
-			}
-			if wordCount {
-				wc = iter.Value().GetWordCount()
-			}
-			chars := ""
-			charFreq := CharacterFrequencies(iter.Value(), 1, 0)
-			pl := RankByFrequency(charFreq)
-			for i, p := range pl {
-				if i < topX {
-					chars = chars + p.Key + ","
-				}
-			}
-			chars = strings.TrimSuffix(chars, ",")
-			if wordCount {
-				if tabDelimit {
-					fmt.Printf("%03d\t%03d\t%v\t%v\n", i, wc, sent, chars)
-				} else {
-					fmt.Printf("%03d: [%03d] %v%v\n", i, wc, sent, chars)
-				}
-			} else {
-				if tabDelimit {
-					fmt.Printf("%03d\t%v\t%v\n", i, sent, chars)
-				} else {
-					fmt.Printf("%03d: %v%v\n", i, sent, chars)
-				}
-
-			}
-
+	a := ChapterCharacterAnalyzer{
+		TopX:               topX,
+		IncludeSentences:   includeSentences,
+		IncludeXthSentence: includeXthSentence,
+		WordCount:          wordCount,
+	}
+	r := a.Analyze(c).(*ChapterCharacterResult)
+	if tabDelimit {
+		for _, row := range r.Rows() {
+			fmt.Println(strings.Join(row, "\t"))
 		}
+		return
 	}
+	fmt.Print(r.String())
 }
 
 func (c *Chunk) PrintChapter(chapter int) {
@@ -0,0 +1,240 @@
+package booktools
+
+import (
+	"fmt"
+	"strings"
+)
+
+func init() {
+	RegisterAnalyzer(ConsistencyAnalyzer{MinEvidence: 3})
+}
+
+var firstPerson = wordSet("i", "me", "my", "mine", "myself", "we", "us", "our", "ours", "ourselves")
+var secondPerson = wordSet("you", "your", "yours", "yourself", "yourselves")
+var thirdPerson = wordSet("he", "him", "his", "himself", "she", "her", "hers", "herself", "they", "them", "their", "theirs", "themselves")
+var subjectPronouns = wordSet("i", "we", "you", "he", "she", "it", "they")
+
+var pastMarkers = wordSet(
+	"was", "were", "had", "did", "said", "went", "came", "saw", "took", "made", "knew", "thought", "felt",
+	"got", "told", "gave", "found", "left", "stood", "sat", "ran", "began", "heard", "held", "brought",
+)
+var presentMarkers = wordSet("is", "are", "am", "has", "does", "says", "goes", "comes", "sees", "takes", "knows")
+
+// Mode is the estimated tense and narrative person of a passage, from
+// the words outside dialogue.
+type Mode struct {
+	Tense  string `json:"tense" yaml:"tense"`
+	Person string `json:"person" yaml:"person"`
+
+	Past    int `json:"past" yaml:"past"`
+	Present int `json:"present" yaml:"present"`
+	First   int `json:"first" yaml:"first"`
+	Second  int `json:"second" yaml:"second"`
+	Third   int `json:"third" yaml:"third"`
+}
+
+func (m *Mode) add(o Mode) {
+	m.Past = m.Past + o.Past
+	m.Present = m.Present + o.Present
+	m.First = m.First + o.First
+	m.Second = m.Second + o.Second
+	m.Third = m.Third + o.Third
+}
+
+// decide settles the tense and person of a paragraph from the counts,
+// requiring at least minEvidence words of each kind. Narrators in the
+// first person still talk about he and she, so a quarter of the
+// pronouns being first person is enough to call it first person.
+func (m *Mode) decide(minEvidence int) {
+	m.Tense, m.Person = "", ""
+	if m.Past+m.Present >= minEvidence {
+		m.Tense = "past"
+		if m.Present > m.Past {
+			m.Tense = "present"
+		}
+	}
+	pronouns := m.First + m.Second + m.Third
+	if pronouns >= minEvidence {
+		switch {
+		case m.First*4 >= pronouns:
+			m.Person = "first"
+		case m.Second*4 >= pronouns:
+			m.Person = "second"
+		default:
+			m.Person = "third"
+		}
+	}
+}
+
+// vote settles the tense and person of a section from the counts of
+// its paragraphs, already decided, taking the person most of them are
+// in. One first person paragraph among many in the third person does
+// not, as it would by decide's quarter, make the section first person.
+// Where no paragraph has enough pronouns to count, the section's person
+// is whichever has the most of them.
+func (m *Mode) vote(paragraphs []Mode, minEvidence int) {
+	m.decide(minEvidence)
+	if m.Person == "" {
+		return
+	}
+	votes := make(map[string]int)
+	for _, p := range paragraphs {
+		if p.Person != "" {
+			votes[p.Person] = votes[p.Person] + 1
+		}
+	}
+	pronouns := map[string]int{"first": m.First, "second": m.Second, "third": m.Third}
+	best := ""
+	for _, person := range []string{"first", "second", "third"} {
+		switch {
+		case best == "",
+			votes[person] > votes[best],
+			votes[person] == votes[best] && pronouns[person] > pronouns[best]:
+			best = person
+		}
+	}
+	m.Person = best
+}
+
+// narrationMode counts the tense and person markers in a paragraph,
+// skipping anything in quotation marks.
+func narrationMode(paragraph *Chunk) Mode {
+	m := Mode{}
+	words, _ := paragraphWords(paragraph)
+	inDialogue := false
+	prev := ""
+	for _, w := range words {
+		text := w.Word
+		if strings.HasPrefix(text, "\"") || strings.HasPrefix(text, "“") {
+			inDialogue = true
+		}
+		closing := strings.HasSuffix(trimClosing(text), "\"") || strings.Contains(text, "”")
+		if !inDialogue {
+			k := WordKey(text)
+			switch {
+			case firstPerson[k]:
+				m.First = m.First + 1
+			case secondPerson[k]:
+				m.Second = m.Second + 1
+			case thirdPerson[k]:
+				m.Third = m.Third + 1
+			}
+			switch {
+			case pastMarkers[k]:
+				m.Past = m.Past + 1
+			case presentMarkers[k]:
+				m.Present = m.Present + 1
+			case subjectPronouns[prev] && len(k) > 3 && strings.HasSuffix(k, "ed"):
+				m.Past = m.Past + 1
+			case (prev == "he" || prev == "she" || prev == "it") && len(k) > 2 && strings.HasSuffix(k, "s") && !strings.HasSuffix(k, "ss"):
+				m.Present = m.Present + 1
+			}
+			prev = k
+		}
+		if closing && (inDialogue || text == "\"") {
+			inDialogue = false
+			prev = ""
+		}
+	}
+	return m
+}
+
+// trimClosing removes sentence punctuation from the end of w,
+// so that a closing quotation mark followed by a comma can be found.
+func trimClosing(w string) string {
+	return strings.TrimRight(w, ".,;:!?)")
+}
+
+// SectionMode is the dominant mode of a section.
+type SectionMode struct {
+	Address Address `json:"address" yaml:"address"`
+	Mode    Mode    `json:"mode" yaml:"mode"`
+}
+
+// ConsistencyFlag is a paragraph whose tense or person differs from
+// that of its section.
+type ConsistencyFlag struct {
+	Address  Address `json:"address" yaml:"address"`
+	Aspect   string  `json:"aspect" yaml:"aspect"`
+	Found    string  `json:"found" yaml:"found"`
+	Expected string  `json:"expected" yaml:"expected"`
+}
+
+// ConsistencyReport lists the mode of each section and the paragraphs
+// which stray from it.
+type ConsistencyReport struct {
+	Sections []SectionMode     `json:"sections" yaml:"sections"`
+	Flags    []ConsistencyFlag `json:"flags" yaml:"flags"`
+}
+
+func (r *ConsistencyReport) Columns() []string {
+	return []string{"Address", "Aspect", "Found", "Expected"}
+}
+
+func (r *ConsistencyReport) Rows() [][]string {
+	rows := make([][]string, len(r.Flags))
+	for i, f := range r.Flags {
+		rows[i] = []string{f.Address.String(), f.Aspect, f.Found, f.Expected}
+	}
+	return rows
+}
+
+func (r *ConsistencyReport) String() string {
+	sb := strings.Builder{}
+	for _, s := range r.Sections {
+		sb.WriteString(fmt.Sprintf("%-10v %-8v %v\n", s.Address, orUnknown(s.Mode.Tense), orUnknown(s.Mode.Person)))
+		for _, f := range r.Flags {
+			if f.Address.Chapter == s.Address.Chapter && f.Address.Section == s.Address.Section {
+				sb.WriteString(fmt.Sprintf("    %-10v %v in %v, not %v\n", f.Address, f.Aspect, f.Found, f.Expected))
+			}
+		}
+	}
+	return sb.String()
+}
+
+func orUnknown(s string) string {
+	if s == "" {
+		return "unknown"
+	}
+	return s
+}
+
+// ConsistencyAnalyzer estimates the dominant tense and narrative person
+// of each section and flags the paragraphs which differ, considering
+// only paragraphs with at least MinEvidence markers of each.
+type ConsistencyAnalyzer struct {
+	MinEvidence int
+}
+
+func (a ConsistencyAnalyzer) Name() string { return "consistency" }
+func (a ConsistencyAnalyzer) Description() string {
+	return "Flags paragraphs whose tense or point of view differs from their section"
+}
+func (a ConsistencyAnalyzer) Unit() int { return Paragraph }
+
+func (a ConsistencyAnalyzer) Analyze(c *Chunk) Result {
+	r := &ConsistencyReport{Sections: make([]SectionMode, 0), Flags: make([]ConsistencyFlag, 0)}
+	c.Walk(Section, func(sa Address, section *Chunk) bool {
+		modes := make([]Mode, len(section.Children))
+		sm := SectionMode{Address: sa}
+		for i, p := range section.Children {
+			modes[i] = narrationMode(p)
+			sm.Mode.add(modes[i])
+			modes[i].decide(a.MinEvidence)
+		}
+		sm.Mode.vote(modes, a.MinEvidence)
+		r.Sections = append(r.Sections, sm)
+		for i, m := range modes {
+			pa := sa
+			pa.Paragraph = i + 1
+			if m.Tense != "" && sm.Mode.Tense != "" && m.Tense != sm.Mode.Tense {
+				r.Flags = append(r.Flags, ConsistencyFlag{Address: pa, Aspect: "tense", Found: m.Tense, Expected: sm.Mode.Tense})
+			}
+			if m.Person != "" && sm.Mode.Person != "" && m.Person != sm.Mode.Person {
+				r.Flags = append(r.Flags, ConsistencyFlag{Address: pa, Aspect: "person", Found: m.Person, Expected: sm.Mode.Person})
+			}
+		}
+		return true
+	})
+	return r
+}
@@ -0,0 +1,87 @@
+package booktools
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestConsistencyAnalyzer(t *testing.T) {
+	tests := []struct {
+		name   string
+		text   string
+		tense  string
+		person string
+		flags  []string
+	}{
+		{
+			"third person past",
+			"Chapter 1\n\nShe walked to the door. He was waiting for her, and they went out.\n\nShe said nothing. He took her hand and they walked on.\n",
+			"past", "third", []string{},
+		},
+		{
+			"one first person paragraph",
+			"Chapter 1\n\nShe walked to the door. He was waiting for her, and they went out.\n\nI was not there. I heard of it later, and my brother told me.\n\nShe said nothing. He took her hand and they walked on.\n\nThey came to the river. She sat and he stood by her.\n",
+			"past", "third", []string{"1.1.3 person first"},
+		},
+		{
+			"first person narrator",
+			"Chapter 1\n\nI walked to the door. He was waiting for me, and we went out.\n\nShe said nothing to him. He took her hand and I followed them.\n\nI sat by the river. My brother stood by me.\n",
+			"past", "first", []string{"1.1.3 person third"},
+		},
+		{
+			"present tense slip",
+			"Chapter 1\n\nShe walks to the door. He is waiting, and she takes his hand.\n\nShe was not ready. He had said so, and she knew it.\n\nShe sees the river. He says nothing, and she goes on.\n",
+			"present", "third", []string{"1.1.3 tense past"},
+		},
+		{
+			"dialogue ignored",
+			"Chapter 1\n\nShe walked to the door. “I am here,” she said. He was waiting for her.\n\nHe took her hand. “We are going,” he said, and they went out.\n",
+			"past", "third", []string{},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := ConsistencyAnalyzer{MinEvidence: 3}.Analyze(Parse(test.text, nil)).(*ConsistencyReport)
+			if len(r.Sections) != 1 {
+				t.Fatalf("%d sections, want 1", len(r.Sections))
+			}
+			if m := r.Sections[0].Mode; m.Tense != test.tense || m.Person != test.person {
+				t.Errorf("section %v %v, want %v %v", m.Tense, m.Person, test.tense, test.person)
+			}
+			flags := make([]string, len(r.Flags))
+			for i, f := range r.Flags {
+				flags[i] = f.Address.String() + " " + f.Aspect + " " + f.Found
+			}
+			if !reflect.DeepEqual(flags, test.flags) {
+				t.Errorf("flags %q, want %q", flags, test.flags)
+			}
+		})
+	}
+}
+
+func TestModeVote(t *testing.T) {
+	tests := []struct {
+		name       string
+		paragraphs []Mode
+		person     string
+	}{
+		{"majority", []Mode{{Third: 5}, {First: 3, Third: 1}, {Third: 4}}, "third"},
+		{"lenient for paragraphs", []Mode{{First: 2, Third: 5}, {First: 2, Third: 6}, {Third: 4}}, "first"},
+		{"tie to more pronouns", []Mode{{First: 3}, {Third: 6}}, "third"},
+		{"no paragraph decided", []Mode{{First: 1}, {Third: 1, Second: 1}, {Third: 1}}, "third"},
+		{"too few pronouns", []Mode{{First: 1}, {Third: 1}}, ""},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			m := Mode{}
+			for i := range test.paragraphs {
+				m.add(test.paragraphs[i])
+				test.paragraphs[i].decide(3)
+			}
+			m.vote(test.paragraphs, 3)
+			if m.Person != test.person {
+				t.Errorf("person %q, want %q", m.Person, test.person)
+			}
+		})
+	}
+}
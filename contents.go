@@ -0,0 +1,63 @@
+package booktools
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+func init() {
+	RegisterAnalyzer(ContentsAnalyzer{})
+}
+
+// ContentsEntry describes one chapter in the table of contents.
+type ContentsEntry struct {
+	Chapter   int    `json:"chapter" yaml:"chapter"`
+	Title     string `json:"title" yaml:"title"`
+	WordCount int    `json:"wordCount" yaml:"wordCount"`
+	FirstLine string `json:"firstLine" yaml:"firstLine"`
+}
+
+// Contents is the table of contents of a work.
+type Contents []ContentsEntry
+
+func (l Contents) Columns() []string { return []string{"Chapter", "Title", "WordCount", "FirstLine"} }
+
+func (l Contents) Rows() [][]string {
+	rows := make([][]string, len(l))
+	for i, e := range l {
+		rows[i] = []string{strconv.Itoa(e.Chapter), e.Title, strconv.Itoa(e.WordCount), e.FirstLine}
+	}
+	return rows
+}
+
+func (l Contents) String() string {
+	sb := strings.Builder{}
+	for _, e := range l {
+		sb.WriteString(fmt.Sprintf("%03d: %v [%d] %v\n", e.Chapter, e.Title, e.WordCount, e.FirstLine))
+	}
+	return sb.String()
+}
+
+// ContentsAnalyzer builds the table of contents. Each chapter's title is
+// its first sentence, and its first line the sentence after that.
+type ContentsAnalyzer struct{}
+
+func (a ContentsAnalyzer) Name() string { return "contents" }
+func (a ContentsAnalyzer) Description() string {
+	return "Lists the chapters with their titles and word counts"
+}
+func (a ContentsAnalyzer) Unit() int { return Chapter }
+
+func (a ContentsAnalyzer) Analyze(c *Chunk) Result {
+	l := make(Contents, 0)
+	for i, chapter := range c.Chapters() {
+		l = append(l, ContentsEntry{
+			Chapter:   i + 1,
+			Title:     strings.TrimSpace(chapter.GetFirstSentence()),
+			WordCount: chapter.GetWordCount(),
+			FirstLine: strings.TrimSpace(chapter.GetNthSentence(2)),
+		})
+	}
+	return l
+}
@@ -0,0 +1,181 @@
+package booktools
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// EchoRules are the rules under which echoes are reported as
+// diagnostics.
+var EchoRules = []LintRule{
+	{"ECHO001", SeverityInfo, "Word repeated soon after an earlier use"},
+	{"ECHO002", SeverityInfo, "Consecutive sentences open with the same word"},
+}
+
+// ConsistencyRules are the rules under which consistency flags are
+// reported as diagnostics.
+var ConsistencyRules = []LintRule{
+	{"TENSE001", SeverityWarning, "Paragraph's tense differs from its section"},
+	{"POV001", SeverityWarning, "Paragraph's point of view differs from its section"},
+}
+
+// DescribeRule returns the built-in rule with the given ID, or a rule
+// with only the ID where there is none, as for rules read from a file.
+func DescribeRule(id string) LintRule {
+	for _, rules := range [][]LintRule{TypographyRules, EchoRules, ConsistencyRules} {
+		for _, r := range rules {
+			if r.ID == id {
+				return r
+			}
+		}
+	}
+	return LintRule{ID: id, Severity: SeverityWarning}
+}
+
+// Diagnostics is implemented by results whose findings can be reported
+// against lines and columns of the source text, as SARIF or checkstyle.
+type Diagnostics interface {
+	Result
+	Diagnose(m *SourceMap) LintFindings
+}
+
+// SourceFile is one of the files read one after another into a text,
+// beginning at the byte offset Start, at the start of a line.
+type SourceFile struct {
+	Name  string
+	Start int
+}
+
+// SourceMap finds where the chunks of a work lie in the text of the
+// files they were read from.
+type SourceMap struct {
+	Files []SourceFile
+
+	root *Chunk
+	text *linter
+}
+
+// NewSourceMap maps the chunks under root onto text, read from file.
+func NewSourceMap(file string, text string, root *Chunk) *SourceMap {
+	return NewSourceMapFiles([]SourceFile{{Name: file}}, text, root)
+}
+
+// NewSourceMapFiles maps the chunks under root onto text, read from
+// files one after another.
+func NewSourceMapFiles(files []SourceFile, text string, root *Chunk) *SourceMap {
+	return &SourceMap{Files: files, root: root, text: newLinter(text)}
+}
+
+// Locate returns the file in which a byte offset in the text lies, and
+// the line and column of the offset within that file.
+func (m *SourceMap) Locate(offset int) (string, int, int) {
+	line, column := m.text.position(offset)
+	i := sort.Search(len(m.Files), func(i int) bool { return m.Files[i].Start > offset }) - 1
+	if i < 0 {
+		if len(m.Files) == 0 {
+			return "", line, column
+		}
+		i = 0
+	}
+	first, _ := m.text.position(m.Files[i].Start)
+	return m.Files[i].Name, line - first + 1, column
+}
+
+// Offset returns the byte offset in the text at which c begins. The
+// Position of a word includes whatever came between it and the word
+// before, such as whitespace or a section marker, which is skipped.
+func (m *SourceMap) Offset(c *Chunk) int {
+	if c == nil {
+		return 0
+	}
+	for c.Unit > Word && len(c.Children) > 0 {
+		c = c.Children[0]
+	}
+	t := m.text.text
+	off, end := int(c.Position), int(c.Position+c.Length)
+	if off > len(t) {
+		return len(t)
+	}
+	if end > len(t) {
+		end = len(t)
+	}
+	if i := strings.Index(t[off:end], c.Word); i >= 0 && c.Word != "" {
+		return off + i
+	}
+	for off < len(t) && (t[off] == ' ' || t[off] == '\t' || t[off] == '\r' || t[off] == '\n') {
+		off++
+	}
+	return off
+}
+
+// End returns the byte offset in the text just after the last word of
+// c, leaving out the whitespace its Length includes.
+func (m *SourceMap) End(c *Chunk) int {
+	if c == nil {
+		return 0
+	}
+	for c.Unit > Word && len(c.Children) > 0 {
+		c = c.Children[len(c.Children)-1]
+	}
+	t := m.text.text
+	end := int(c.Position + c.Length)
+	if end > len(t) {
+		end = len(t)
+	}
+	for end > 0 && (t[end-1] == ' ' || t[end-1] == '\t' || t[end-1] == '\r' || t[end-1] == '\n') {
+		end--
+	}
+	if start := m.Offset(c); end < start {
+		end = start
+	}
+	return end
+}
+
+// Finding places a finding under rule id at chunk c.
+func (m *SourceMap) Finding(id string, c *Chunk, message string) LintFinding {
+	off := m.Offset(c)
+	file, line, column := m.Locate(off)
+	return LintFinding{File: file, Rule: id, Severity: DescribeRule(id).Severity, Line: line, Column: column, Message: message, offset: off}
+}
+
+// Diagnose returns the findings placed in the files of m, where they
+// were not already placed in a file.
+func (l LintFindings) Diagnose(m *SourceMap) LintFindings {
+	for i := range l {
+		if l[i].File == "" {
+			l[i].File, l[i].Line, l[i].Column = m.Locate(l[i].offset)
+		}
+	}
+	return l
+}
+
+// Diagnose reports each echo at its second use, as ECHO001 for words
+// and ECHO002 for sentence openers.
+func (l EchoList) Diagnose(m *SourceMap) LintFindings {
+	findings := make(LintFindings, 0, len(l))
+	for _, e := range l {
+		id := "ECHO001"
+		if e.Kind == "opener" {
+			id = "ECHO002"
+		}
+		findings = append(findings, m.Finding(id, e.Second, e.String()))
+	}
+	return findings
+}
+
+// Diagnose reports each flagged paragraph at its first word, as
+// TENSE001 or POV001.
+func (r *ConsistencyReport) Diagnose(m *SourceMap) LintFindings {
+	findings := make(LintFindings, 0, len(r.Flags))
+	for _, f := range r.Flags {
+		id := "TENSE001"
+		message := fmt.Sprintf("Paragraph in the %v tense, where its section is in the %v", f.Found, f.Expected)
+		if f.Aspect == "person" {
+			id = "POV001"
+			message = fmt.Sprintf("Paragraph in the %v person, where its section is in the %v", f.Found, f.Expected)
+		}
+		findings = append(findings, m.Finding(id, m.root.Lookup(f.Address), message))
+	}
+	return findings
+}
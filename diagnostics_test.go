@@ -0,0 +1,77 @@
+package booktools
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSourceMapLocate(t *testing.T) {
+	a := "Chapter 1\n\nThe cat sat.\n"
+	b := "Chapter 2\r\n\r\nShe walked home.\r\n"
+	text := a + "\n\n" + b
+	m := NewSourceMapFiles([]SourceFile{{Name: "a.txt"}, {Name: "b.txt", Start: len(a) + 2}}, text, Parse(text, nil))
+	tests := []struct {
+		offset int
+		file   string
+		line   int
+		column int
+	}{
+		{0, "a.txt", 1, 1},
+		{len("Chapter 1\n\nThe "), "a.txt", 3, 5},
+		{len(a) + 2, "b.txt", 1, 1},
+		{len(a) + 2 + len("Chapter 2\r\n\r\nShe "), "b.txt", 3, 5},
+	}
+	for _, test := range tests {
+		file, line, column := m.Locate(test.offset)
+		if file != test.file || line != test.line || column != test.column {
+			t.Errorf("Locate(%d) = %v:%d:%d, want %v:%d:%d", test.offset, file, line, column, test.file, test.line, test.column)
+		}
+	}
+}
+
+func TestSourceMapFinding(t *testing.T) {
+	a := "Chapter 1\n\nThe cat sat on the mat.\n"
+	b := "Chapter 2\n\nShe walked home. She walked on.\n"
+	text := a + "\n\n" + b
+	root := Parse(text, nil)
+	m := NewSourceMapFiles([]SourceFile{{Name: "a.txt"}, {Name: "b.txt", Start: len(a) + 2}}, text, root)
+	tests := []struct {
+		address string
+		file    string
+		line    int
+		column  int
+	}{
+		{"1.1.2", "a.txt", 3, 1},
+		{"2.1.2", "b.txt", 3, 1},
+	}
+	for _, test := range tests {
+		address, err := ParseAddress(test.address)
+		if err != nil {
+			t.Fatal(err)
+		}
+		f := m.Finding("ECHO001", root.Lookup(address), "")
+		if f.File != test.file || f.Line != test.line || f.Column != test.column {
+			t.Errorf("Finding at %v = %v:%d:%d, want %v:%d:%d", test.address, f.File, f.Line, f.Column, test.file, test.line, test.column)
+		}
+	}
+	if f := m.Finding("ECHO001", root.Lookup(Address{Chapter: 2, Section: 1, Paragraph: 2, Sentence: 2}), ""); f.Line != 3 || f.Column != 18 {
+		t.Errorf("Finding at the second sentence of b.txt = %d:%d, want 3:18", f.Line, f.Column)
+	}
+}
+
+func TestDiagnose(t *testing.T) {
+	text := "Chapter 1\n\nShe walked to the door. He was waiting for her, and they went out.\n\n" +
+		"I was not there. I heard of it later, and my brother told me.\n\n" +
+		"She said nothing. He took her hand and they walked on.\n\n" +
+		"They came to the river. She sat and he stood by her.\n"
+	root := Parse(text, nil)
+	m := NewSourceMap("a.txt", text, root)
+	l := ConsistencyAnalyzer{MinEvidence: 3}.Analyze(root).(*ConsistencyReport).Diagnose(m)
+	if keys := findingKeys(l); len(keys) != 1 || keys[0] != "POV001@5:1" || l[0].File != "a.txt" {
+		t.Errorf("consistency findings %v in %v, want [POV001@5:1] in a.txt", keys, l)
+	}
+	l = EchoAnalyzer{Window: 50, MinLength: 4}.Analyze(root).(EchoList).Diagnose(m)
+	if keys, want := findingKeys(l), []string{"ECHO002@5:18", "ECHO001@7:45"}; !reflect.DeepEqual(keys, want) {
+		t.Errorf("echo findings %v, want %v", keys, want)
+	}
+}
@@ -0,0 +1,640 @@
+package booktools
+
+import (
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Kinds of change found by Diff
+const (
+	ChangeAdded     = "added"
+	ChangeRemoved   = "removed"
+	ChangeRewritten = "rewritten"
+	ChangeMoved     = "moved"
+	ChangeChanged   = "changed"
+	ChangeUnchanged = "unchanged"
+)
+
+// ChapterChange describes what became of a chapter between two drafts.
+// Old or New is 0 where the chapter was added or removed. A chapter is
+// moved where it comes in a different order among the others.
+type ChapterChange struct {
+	Kind     string `json:"kind" yaml:"kind"`
+	Old      int    `json:"old,omitempty" yaml:"old,omitempty"`
+	New      int    `json:"new,omitempty" yaml:"new,omitempty"`
+	Title    string `json:"title" yaml:"title"`
+	OldWords int    `json:"oldWords" yaml:"oldWords"`
+	NewWords int    `json:"newWords" yaml:"newWords"`
+}
+
+// Delta is the change in the chapter's word count.
+func (c ChapterChange) Delta() int { return c.NewWords - c.OldWords }
+
+// SectionChange records a section, or scene, which moved to another
+// chapter or to another place in its own.
+type SectionChange struct {
+	Kind  string  `json:"kind" yaml:"kind"`
+	Old   Address `json:"old" yaml:"old"`
+	New   Address `json:"new" yaml:"new"`
+	Words int     `json:"words" yaml:"words"`
+}
+
+// ParagraphChange records a paragraph which was added, removed,
+// rewritten in place, or moved, with how much of its wording the old
+// and new versions share.
+type ParagraphChange struct {
+	Kind       string  `json:"kind" yaml:"kind"`
+	Old        Address `json:"old,omitempty" yaml:"old,omitempty"`
+	New        Address `json:"new,omitempty" yaml:"new,omitempty"`
+	Similarity float64 `json:"similarity,omitempty" yaml:"similarity,omitempty"`
+	Text       string  `json:"text" yaml:"text"`
+}
+
+// CharacterChange is the change in how often a character is named.
+type CharacterChange struct {
+	Name string `json:"name" yaml:"name"`
+	Old  int    `json:"old" yaml:"old"`
+	New  int    `json:"new" yaml:"new"`
+}
+
+// ManuscriptDiff is the structural difference between two drafts of a
+// work.
+type ManuscriptDiff struct {
+	OldWords   int               `json:"oldWords" yaml:"oldWords"`
+	NewWords   int               `json:"newWords" yaml:"newWords"`
+	Chapters   []ChapterChange   `json:"chapters" yaml:"chapters"`
+	Sections   []SectionChange   `json:"sections" yaml:"sections"`
+	Paragraphs []ParagraphChange `json:"paragraphs" yaml:"paragraphs"`
+	Characters []CharacterChange `json:"characters" yaml:"characters"`
+
+	old, new []diffParagraph
+	oldTo    []int
+	newTo    []int
+	inOrder  map[int]bool
+}
+
+// diffParagraph is a paragraph prepared for comparison.
+type diffParagraph struct {
+	address Address
+	section int
+	words   []string
+	counts  map[string]int
+	hash    uint64
+}
+
+func diffParagraphs(root *Chunk) []diffParagraph {
+	l := make([]diffParagraph, 0)
+	section, last := -1, Address{}
+	root.Walk(Paragraph, func(a Address, p *Chunk) bool {
+		if a.Chapter != last.Chapter || a.Section != last.Section {
+			section++
+			last = a
+		}
+		d := diffParagraph{address: a, section: section, counts: make(map[string]int)}
+		h := fnv.New64a()
+		for _, s := range p.Children {
+			for _, w := range s.Children {
+				d.words = append(d.words, w.Word)
+				n := NormalizeWord(w.Word)
+				d.counts[n]++
+				h.Write([]byte(w.Word))
+				h.Write([]byte{' '})
+			}
+		}
+		d.hash = h.Sum64()
+		l = append(l, d)
+		return true
+	})
+	return l
+}
+
+func (d diffParagraph) text() string { return strings.Join(d.words, " ") }
+
+// similarity is the Dice coefficient of the words of two paragraphs:
+// the share of their words they have in common.
+func similarity(a, b diffParagraph) float64 {
+	if len(a.words)+len(b.words) == 0 {
+		return 1
+	}
+	common := 0
+	for w, n := range a.counts {
+		if m, ok := b.counts[w]; ok {
+			if m < n {
+				n = m
+			}
+			common = common + n
+		}
+	}
+	return float64(2*common) / float64(len(a.words)+len(b.words))
+}
+
+// Diff compares two drafts of a work. Paragraphs are paired first by
+// their content, where it is unchanged, then by similarity, which finds
+// those rewritten; what remains was added or removed. Chapters and
+// sections are paired with the ones they share most paragraphs with.
+// Paragraphs rewritten less than minSimilarity of their words apart
+// count as one removed and another added.
+func Diff(old *Chunk, new *Chunk, minSimilarity float64) *ManuscriptDiff {
+	d := &ManuscriptDiff{
+		OldWords: old.GetWordCount(),
+		NewWords: new.GetWordCount(),
+		old:      diffParagraphs(old),
+		new:      diffParagraphs(new),
+	}
+	d.align(minSimilarity)
+	chapters := d.compareChapters(old, new)
+	sections := d.compareSections(old, new, chapters)
+	d.compareParagraphs(sections)
+	d.compareCharacters(old, new)
+	return d
+}
+
+// align pairs the old and new paragraphs, filling in oldTo and newTo,
+// and inOrder with the new paragraphs whose pairs keep their order.
+func (d *ManuscriptDiff) align(minSimilarity float64) {
+	d.oldTo = make([]int, len(d.old))
+	d.newTo = make([]int, len(d.new))
+	for i := range d.oldTo {
+		d.oldTo[i] = -1
+	}
+	for j := range d.newTo {
+		d.newTo[j] = -1
+	}
+	pair := func(i, j int) {
+		d.oldTo[i] = j
+		d.newTo[j] = i
+	}
+
+	// Paragraphs which appear once in each draft anchor the rest, as
+	// in patience diff, so long as they keep their order.
+	oldByHash := make(map[uint64][]int)
+	newByHash := make(map[uint64][]int)
+	for i, p := range d.old {
+		oldByHash[p.hash] = append(oldByHash[p.hash], i)
+	}
+	for j, p := range d.new {
+		newByHash[p.hash] = append(newByHash[p.hash], j)
+	}
+	unique := make([][2]int, 0)
+	for j, p := range d.new {
+		if len(newByHash[p.hash]) == 1 && len(oldByHash[p.hash]) == 1 {
+			unique = append(unique, [2]int{oldByHash[p.hash][0], j})
+		}
+	}
+	for _, k := range increasing(unique) {
+		pair(unique[k][0], unique[k][1])
+	}
+	// Other unchanged paragraphs, repeated or moved, pair in turn.
+	for j, p := range d.new {
+		if d.newTo[j] >= 0 {
+			continue
+		}
+		for _, i := range oldByHash[p.hash] {
+			if d.oldTo[i] < 0 {
+				pair(i, j)
+				break
+			}
+		}
+	}
+
+	// Rewritten paragraphs are looked for first near where they were,
+	// between the paragraphs on either side which are paired, then
+	// anywhere among what remains.
+	const window = 50
+	d.order()
+	gapOld, gapNew := 0, 0
+	for j := 0; j <= len(d.new); j++ {
+		if j < len(d.new) && !d.inOrder[j] {
+			continue
+		}
+		endOld := len(d.old)
+		if j < len(d.new) {
+			endOld = d.newTo[j]
+		}
+		d.pairSimilar(gapOld, endOld, gapNew, j, window, minSimilarity)
+		gapOld, gapNew = endOld+1, j+1
+	}
+	d.pairSimilar(0, len(d.old), 0, len(d.new), -1, (1+minSimilarity)/2)
+	d.order()
+}
+
+// order finds the new paragraphs whose pairs keep their order.
+func (d *ManuscriptDiff) order() {
+	pairs := make([][2]int, 0)
+	for j, i := range d.newTo {
+		if i >= 0 {
+			pairs = append(pairs, [2]int{i, j})
+		}
+	}
+	d.inOrder = make(map[int]bool)
+	for _, k := range increasing(pairs) {
+		d.inOrder[pairs[k][1]] = true
+	}
+}
+
+// pairSimilar pairs the unpaired old paragraphs from oldStart to oldEnd
+// with the unpaired new ones from newStart to newEnd which share at
+// least min of their words, most similar first. Where window is not
+// negative, only paragraphs within window places of each other are
+// compared.
+func (d *ManuscriptDiff) pairSimilar(oldStart, oldEnd, newStart, newEnd int, window int, min float64) {
+	type candidate struct {
+		i, j int
+		sim  float64
+	}
+	olds := make([]int, 0)
+	for i := oldStart; i < oldEnd; i++ {
+		if d.oldTo[i] < 0 {
+			olds = append(olds, i)
+		}
+	}
+	news := make([]int, 0)
+	for j := newStart; j < newEnd; j++ {
+		if d.newTo[j] < 0 {
+			news = append(news, j)
+		}
+	}
+	if window < 0 && len(olds)*len(news) > 1000000 {
+		// Too much has changed to look everywhere.
+		return
+	}
+	candidates := make([]candidate, 0)
+	for x, i := range olds {
+		for y, j := range news {
+			if window >= 0 && (x-y > window || y-x > window) {
+				continue
+			}
+			if sim := similarity(d.old[i], d.new[j]); sim >= min {
+				candidates = append(candidates, candidate{i, j, sim})
+			}
+		}
+	}
+	sort.SliceStable(candidates, func(a, b int) bool { return candidates[a].sim > candidates[b].sim })
+	for _, c := range candidates {
+		if d.oldTo[c.i] < 0 && d.newTo[c.j] < 0 {
+			d.oldTo[c.i] = c.j
+			d.newTo[c.j] = c.i
+		}
+	}
+}
+
+// increasing returns the indexes of the longest run of pairs, taken in
+// the order given, whose first members also increase: the pairs which
+// keep their order.
+func increasing(pairs [][2]int) []int {
+	// Patience sorting, keeping the pile tops and back links.
+	tops := make([]int, 0)
+	prev := make([]int, len(pairs))
+	for k, p := range pairs {
+		n := sort.Search(len(tops), func(t int) bool { return pairs[tops[t]][0] >= p[0] })
+		prev[k] = -1
+		if n > 0 {
+			prev[k] = tops[n-1]
+		}
+		if n == len(tops) {
+			tops = append(tops, k)
+		} else {
+			tops[n] = k
+		}
+	}
+	l := make([]int, len(tops))
+	k := -1
+	if len(tops) > 0 {
+		k = tops[len(tops)-1]
+	}
+	for n := len(tops) - 1; n >= 0; n-- {
+		l[n] = k
+		k = prev[k]
+	}
+	return l
+}
+
+// matchUnits pairs old and new chapters or sections, given the one each
+// paragraph belongs to, with the one they share most words with, where
+// that choice is mutual. It returns the new unit paired with each old
+// one, or -1.
+func (d *ManuscriptDiff) matchUnits(oldUnit func(p diffParagraph) int, newUnit func(p diffParagraph) int, nOld, nNew int) []int {
+	shared := make(map[[2]int]int)
+	for i, j := range d.oldTo {
+		if j >= 0 {
+			shared[[2]int{oldUnit(d.old[i]), newUnit(d.new[j])}] += len(d.new[j].words) + 1
+		}
+	}
+	bestNew := make([]int, nOld)
+	bestOld := make([]int, nNew)
+	mostNew := make([]int, nOld)
+	mostOld := make([]int, nNew)
+	for i := range bestNew {
+		bestNew[i] = -1
+	}
+	for j := range bestOld {
+		bestOld[j] = -1
+	}
+	keys := make([][2]int, 0, len(shared))
+	for k := range shared {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(a, b int) bool {
+		return keys[a][0] < keys[b][0] || keys[a][0] == keys[b][0] && keys[a][1] < keys[b][1]
+	})
+	for _, k := range keys {
+		n := shared[k]
+		if n > mostNew[k[0]] {
+			mostNew[k[0]], bestNew[k[0]] = n, k[1]
+		}
+		if n > mostOld[k[1]] {
+			mostOld[k[1]], bestOld[k[1]] = n, k[0]
+		}
+	}
+	for i, j := range bestNew {
+		if j >= 0 && bestOld[j] != i {
+			bestNew[i] = -1
+		}
+	}
+	return bestNew
+}
+
+// moved returns the new units, of those paired in oldTo, which are out
+// of order with the rest.
+func moved(oldTo []int) map[int]bool {
+	pairs := make([][2]int, 0)
+	newTo := make(map[int]int)
+	for i, j := range oldTo {
+		if j >= 0 {
+			newTo[j] = i
+		}
+	}
+	keys := make([]int, 0, len(newTo))
+	for j := range newTo {
+		keys = append(keys, j)
+	}
+	sort.Ints(keys)
+	for _, j := range keys {
+		pairs = append(pairs, [2]int{newTo[j], j})
+	}
+	m := make(map[int]bool)
+	for _, j := range keys {
+		m[j] = true
+	}
+	for _, k := range increasing(pairs) {
+		delete(m, pairs[k][1])
+	}
+	return m
+}
+
+// unitMap is the pairing of old and new chapters or sections, and which
+// of the new ones moved.
+type unitMap struct {
+	oldTo []int
+	moved map[int]bool
+}
+
+func (d *ManuscriptDiff) compareChapters(old *Chunk, new *Chunk) unitMap {
+	oldChapters, newChapters := old.Chapters(), new.Chapters()
+	m := unitMap{oldTo: d.matchUnits(
+		func(p diffParagraph) int { return p.address.Chapter - 1 },
+		func(p diffParagraph) int { return p.address.Chapter - 1 },
+		len(oldChapters), len(newChapters))}
+	m.moved = moved(m.oldTo)
+	newFrom := make(map[int]int)
+	for i, j := range m.oldTo {
+		if j >= 0 {
+			newFrom[j] = i
+		}
+	}
+	title := func(c *Chunk) string { return strings.TrimSpace(c.GetFirstSentence()) }
+	next := 0
+	removed := func(upTo int) {
+		for ; next < upTo; next++ {
+			if m.oldTo[next] < 0 {
+				c := oldChapters[next]
+				d.Chapters = append(d.Chapters, ChapterChange{Kind: ChangeRemoved, Old: next + 1, Title: title(c), OldWords: c.GetWordCount()})
+			}
+		}
+	}
+	for j, c := range newChapters {
+		change := ChapterChange{Kind: ChangeAdded, New: j + 1, Title: title(c), NewWords: c.GetWordCount()}
+		if i, ok := newFrom[j]; ok {
+			if !m.moved[j] {
+				removed(i)
+			}
+			change.Old = i + 1
+			change.OldWords = oldChapters[i].GetWordCount()
+			switch {
+			case m.moved[j]:
+				change.Kind = ChangeMoved
+			case d.chapterChanged(i, j):
+				change.Kind = ChangeChanged
+			default:
+				change.Kind = ChangeUnchanged
+			}
+		}
+		d.Chapters = append(d.Chapters, change)
+	}
+	removed(len(oldChapters))
+	return m
+}
+
+// chapterChanged reports whether any paragraph of the old chapter i or
+// new chapter j is not an unchanged paragraph of the other, in the same
+// order.
+func (d *ManuscriptDiff) chapterChanged(i, j int) bool {
+	for x, p := range d.old {
+		if p.address.Chapter == i+1 {
+			y := d.oldTo[x]
+			if y < 0 || d.new[y].address.Chapter != j+1 || d.new[y].hash != p.hash {
+				return true
+			}
+		}
+	}
+	last := -1
+	for y, p := range d.new {
+		if p.address.Chapter == j+1 {
+			x := d.newTo[y]
+			if x < 0 || d.old[x].address.Chapter != i+1 || x < last {
+				return true
+			}
+			last = x
+		}
+	}
+	return false
+}
+
+func (d *ManuscriptDiff) compareSections(old *Chunk, new *Chunk, chapters unitMap) unitMap {
+	oldSections, newSections := old.Find(Section), new.Find(Section)
+	m := unitMap{oldTo: d.matchUnits(
+		func(p diffParagraph) int { return p.section },
+		func(p diffParagraph) int { return p.section },
+		len(oldSections), len(newSections))}
+	m.moved = make(map[int]bool)
+	oldAddress := sectionAddresses(d.old, len(oldSections))
+	newAddress := sectionAddresses(d.new, len(newSections))
+	// A section keeps its place where it stayed in its chapter, or the
+	// chapter moved with it, and its order among the others which did.
+	byChapter := make(map[int][][2]int)
+	for i, j := range m.oldTo {
+		if j >= 0 && chapters.oldTo[oldAddress[i].Chapter-1] == newAddress[j].Chapter-1 {
+			byChapter[newAddress[j].Chapter] = append(byChapter[newAddress[j].Chapter], [2]int{j, i})
+		}
+	}
+	stayed := make(map[int]bool)
+	for _, pairs := range byChapter {
+		for _, k := range increasing(pairs) {
+			stayed[pairs[k][0]] = true
+		}
+	}
+	for i, j := range m.oldTo {
+		if j < 0 || stayed[j] {
+			continue
+		}
+		m.moved[j] = true
+		d.Sections = append(d.Sections, SectionChange{Kind: ChangeMoved, Old: oldAddress[i], New: newAddress[j], Words: newSections[j].GetWordCount()})
+	}
+	sort.Slice(d.Sections, func(a, b int) bool { return d.Sections[a].New.Before(d.Sections[b].New) })
+	return m
+}
+
+func sectionAddresses(paragraphs []diffParagraph, n int) []Address {
+	l := make([]Address, n)
+	for _, p := range paragraphs {
+		if p.section < n {
+			l[p.section] = Address{Chapter: p.address.Chapter, Section: p.address.Section}
+		}
+	}
+	return l
+}
+
+// compareParagraphs lists the paragraphs added, removed, rewritten and
+// moved. A paragraph has moved where it left its section, or changed
+// places within it; those in a section which moved whole have not.
+func (d *ManuscriptDiff) compareParagraphs(sections unitMap) {
+	bySection := make(map[int][][2]int)
+	for j, i := range d.newTo {
+		if i >= 0 && sections.oldTo[d.old[i].section] == d.new[j].section {
+			bySection[d.new[j].section] = append(bySection[d.new[j].section], [2]int{i, j})
+		}
+	}
+	stayed := make(map[int]bool)
+	for _, pairs := range bySection {
+		for _, k := range increasing(pairs) {
+			stayed[pairs[k][1]] = true
+		}
+	}
+	for j, p := range d.new {
+		i := d.newTo[j]
+		if i < 0 {
+			d.Paragraphs = append(d.Paragraphs, ParagraphChange{Kind: ChangeAdded, New: p.address, Text: p.text()})
+			continue
+		}
+		change := ParagraphChange{Kind: ChangeRewritten, Old: d.old[i].address, New: p.address, Text: p.text()}
+		switch {
+		case !stayed[j]:
+			change.Kind = ChangeMoved
+		case d.old[i].hash == p.hash:
+			continue
+		}
+		if d.old[i].hash != p.hash {
+			change.Similarity = similarity(d.old[i], p)
+		}
+		d.Paragraphs = append(d.Paragraphs, change)
+	}
+	for i, p := range d.old {
+		if d.oldTo[i] < 0 {
+			d.Paragraphs = append(d.Paragraphs, ParagraphChange{Kind: ChangeRemoved, Old: p.address, Text: p.text()})
+		}
+	}
+}
+
+func (d *ManuscriptDiff) compareCharacters(old *Chunk, new *Chunk) {
+	oldNames := CharacterFrequencies(old, 3, 1)
+	newNames := CharacterFrequencies(new, 3, 1)
+	for name, n := range newNames {
+		if oldNames[name] != n {
+			d.Characters = append(d.Characters, CharacterChange{Name: name, Old: oldNames[name], New: n})
+		}
+	}
+	for name, n := range oldNames {
+		if _, ok := newNames[name]; !ok {
+			d.Characters = append(d.Characters, CharacterChange{Name: name, Old: n})
+		}
+	}
+	sort.Slice(d.Characters, func(a, b int) bool { return d.Characters[a].Name < d.Characters[b].Name })
+}
+
+// orNone writes an unset address as "-".
+func orNone(a Address) string {
+	if a.Chapter == 0 {
+		return "-"
+	}
+	return a.String()
+}
+
+func (d *ManuscriptDiff) Columns() []string {
+	return []string{"Unit", "Change", "Old", "New", "Detail"}
+}
+
+func (d *ManuscriptDiff) Rows() [][]string {
+	rows := make([][]string, 0)
+	for _, c := range d.Chapters {
+		rows = append(rows, []string{"chapter", c.Kind, orNone(Address{Chapter: c.Old}), orNone(Address{Chapter: c.New}), fmt.Sprintf("%+d words", c.Delta())})
+	}
+	for _, s := range d.Sections {
+		rows = append(rows, []string{"section", s.Kind, s.Old.String(), s.New.String(), fmt.Sprintf("%d words", s.Words)})
+	}
+	for _, p := range d.Paragraphs {
+		detail := ""
+		if p.Similarity > 0 {
+			detail = fmt.Sprintf("%.0f%% similar", 100*p.Similarity)
+		}
+		rows = append(rows, []string{"paragraph", p.Kind, orNone(p.Old), orNone(p.New), detail})
+	}
+	for _, c := range d.Characters {
+		rows = append(rows, []string{"character", ChangeChanged, c.Name, c.Name, fmt.Sprintf("%d to %d", c.Old, c.New)})
+	}
+	return rows
+}
+
+func (d *ManuscriptDiff) String() string {
+	sb := strings.Builder{}
+	sb.WriteString(fmt.Sprintf("Words: %d -> %d (%+d)\n", d.OldWords, d.NewWords, d.NewWords-d.OldWords))
+	sb.WriteString("\nChapters\n")
+	for _, c := range d.Chapters {
+		sb.WriteString(fmt.Sprintf("  %3v -> %-3v %-9v %+6d  %v\n", orNone(Address{Chapter: c.Old}), orNone(Address{Chapter: c.New}), c.Kind, c.Delta(), abbreviate(c.Title, 50)))
+	}
+	if len(d.Sections) > 0 {
+		sb.WriteString("\nSections moved\n")
+		for _, s := range d.Sections {
+			sb.WriteString(fmt.Sprintf("  %v -> %v (%d words)\n", s.Old, s.New, s.Words))
+		}
+	}
+	if len(d.Paragraphs) > 0 {
+		sb.WriteString("\nParagraphs\n")
+		for _, p := range d.Paragraphs {
+			similar := ""
+			if p.Similarity > 0 {
+				similar = " " + strconv.Itoa(int(100*p.Similarity)) + "%"
+			}
+			sb.WriteString(fmt.Sprintf("  %-9v %v -> %v%v  %v\n", p.Kind, orNone(p.Old), orNone(p.New), similar, abbreviate(p.Text, 60)))
+		}
+	}
+	if len(d.Characters) > 0 {
+		sb.WriteString("\nCharacters\n")
+		for _, c := range d.Characters {
+			sb.WriteString(fmt.Sprintf("  %-20v %4d -> %-4d (%+d)\n", c.Name, c.Old, c.New, c.New-c.Old))
+		}
+	}
+	return sb.String()
+}
+
+// abbreviate shortens s to at most n runes, ending it with an ellipsis
+// where it was cut.
+func abbreviate(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return strings.TrimSpace(string(r[:n-1])) + "…"
+}
@@ -0,0 +1,219 @@
+package booktools
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestIncreasing(t *testing.T) {
+	tests := []struct {
+		pairs [][2]int
+		want  []int
+	}{
+		{[][2]int{}, []int{}},
+		{[][2]int{{0, 0}, {1, 1}, {2, 2}}, []int{0, 1, 2}},
+		{[][2]int{{2, 0}, {0, 1}, {1, 2}}, []int{1, 2}},
+		{[][2]int{{0, 0}, {3, 1}, {1, 2}, {2, 3}, {4, 4}}, []int{0, 2, 3, 4}},
+		{[][2]int{{3, 0}, {2, 1}, {1, 2}, {0, 3}}, []int{3}},
+	}
+	for _, test := range tests {
+		if got := increasing(test.pairs); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("increasing(%v) = %v, want %v", test.pairs, got, test.want)
+		}
+	}
+}
+
+func TestSimilarity(t *testing.T) {
+	p := func(text string) diffParagraph {
+		return diffParagraphs(Parse(text+"\n", nil))[0]
+	}
+	tests := []struct {
+		a, b string
+		want float64
+	}{
+		{"Anna walked home.", "Anna walked home.", 1},
+		{"Anna walked home.", "Ben rode away.", 0},
+		{"Anna walked home slowly.", "Anna walked home.", 6.0 / 7},
+		{"the the cat", "the cat cat", 4.0 / 6},
+	}
+	for _, test := range tests {
+		if got := similarity(p(test.a), p(test.b)); got != test.want {
+			t.Errorf("similarity(%q, %q) = %v, want %v", test.a, test.b, got, test.want)
+		}
+	}
+}
+
+// diffParagraph texts, each distinct from the others.
+var diffText = map[string]string{
+	"A": "Anna woke before dawn and lay listening to the rain on the roof.",
+	"B": "Her brother had gone to the city three days before and not written.",
+	"C": "She dressed in the dark and went down to light the kitchen fire.",
+	"D": "Idris was already there, sitting at the table with a cold cup of tea.",
+	"E": "He would not say where he had been, and she did not ask him again.",
+	"F": "By noon the rain had stopped and the yard was full of standing water.",
+	"G": "They walked out together along the river as far as the old mill.",
+	"H": "The miller's dog came barking to the gate and would not be quieted.",
+}
+
+// diffDraft builds a work from chapters of sections of paragraphs, as
+// in "A B | C, D": chapters split by "|", sections by ",". A paragraph
+// ending in "+" has a few words added to it. Each chapter is headed by
+// its first paragraph's name, so that the heading moves with it.
+func diffDraft(layout string) *Chunk {
+	sb := strings.Builder{}
+	for _, chapter := range strings.Split(layout, "|") {
+		sb.WriteString("Chapter " + strings.TrimSuffix(strings.Fields(chapter)[0], "+") + "\n\n")
+		for s, section := range strings.Split(chapter, ",") {
+			if s > 0 {
+				sb.WriteString("---\n\n")
+			}
+			for _, p := range strings.Fields(section) {
+				text := diffText[strings.TrimSuffix(p, "+")]
+				if strings.HasSuffix(p, "+") {
+					text = strings.TrimSuffix(text, ".") + " once more that morning."
+				}
+				sb.WriteString(text + "\n\n")
+			}
+		}
+	}
+	return Parse(sb.String(), nil)
+}
+
+func TestDiff(t *testing.T) {
+	tests := []struct {
+		name       string
+		old, new   string
+		chapters   []string
+		sections   []string
+		paragraphs []string
+	}{
+		{
+			"unchanged",
+			"A B C | D E", "A B C | D E",
+			[]string{"unchanged 1 1", "unchanged 2 2"},
+			[]string{},
+			[]string{},
+		},
+		{
+			"added and removed",
+			"A B C | D E", "A C | D E F",
+			[]string{"changed 1 1", "changed 2 2"},
+			[]string{},
+			[]string{"added - 2.1.4", "removed 1.1.3 -"},
+		},
+		{
+			"rewritten",
+			"A B C | D E", "A B+ C | D E",
+			[]string{"changed 1 1", "unchanged 2 2"},
+			[]string{},
+			[]string{"rewritten 1.1.3 1.1.3"},
+		},
+		{
+			"paragraph moved within a chapter",
+			"A B C D | E F", "A C D B | E F",
+			[]string{"changed 1 1", "unchanged 2 2"},
+			[]string{},
+			[]string{"moved 1.1.3 1.1.5"},
+		},
+		{
+			"paragraph moved and rewritten",
+			"A B C D | E F", "A C D | E B+ F",
+			[]string{"changed 1 1", "changed 2 2"},
+			[]string{},
+			[]string{"moved 1.1.3 2.1.3"},
+		},
+		{
+			"chapters swapped",
+			"A B | C D | E F", "A B | E F | C D",
+			[]string{"unchanged 1 1", "moved 3 2", "unchanged 2 3"},
+			[]string{},
+			[]string{},
+		},
+		{
+			"chapter added",
+			"A B | C D", "A B | G H | C D",
+			[]string{"unchanged 1 1", "added - 2", "unchanged 2 3"},
+			[]string{},
+			[]string{"added - 2.1.1", "added - 2.1.2", "added - 2.1.3"},
+		},
+		{
+			"chapter removed",
+			"A B | G H | C D", "A B | C D",
+			[]string{"unchanged 1 1", "removed 2 -", "unchanged 3 2"},
+			[]string{},
+			[]string{"removed 2.1.1 -", "removed 2.1.2 -", "removed 2.1.3 -"},
+		},
+		{
+			"section moved to another chapter",
+			"A B, C D | E F", "A B | E F, C D",
+			[]string{"changed 1 1", "changed 2 2"},
+			[]string{"1.2 2.2"},
+			[]string{},
+		},
+		{
+			"sections swapped",
+			"A B, C D, E F", "A B, E F, C D",
+			[]string{"changed 1 1"},
+			[]string{"1.2 1.3"},
+			[]string{},
+		},
+		{
+			"chapter moved with its sections",
+			"A B | C D, E F", "C D, E F | A B",
+			[]string{"moved 2 1", "unchanged 1 2"},
+			[]string{},
+			[]string{},
+		},
+		{
+			"repeated paragraph",
+			"A B A C", "A B C A",
+			[]string{"changed 1 1"},
+			[]string{},
+			[]string{"moved 1.1.5 1.1.4"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			d := Diff(diffDraft(test.old), diffDraft(test.new), 0.5)
+			chapters := make([]string, len(d.Chapters))
+			for i, c := range d.Chapters {
+				chapters[i] = c.Kind + " " + orNone(Address{Chapter: c.Old}) + " " + orNone(Address{Chapter: c.New})
+			}
+			if !reflect.DeepEqual(chapters, test.chapters) {
+				t.Errorf("chapters %q, want %q", chapters, test.chapters)
+			}
+			sections := make([]string, len(d.Sections))
+			for i, s := range d.Sections {
+				sections[i] = s.Old.String() + " " + s.New.String()
+			}
+			if !reflect.DeepEqual(sections, test.sections) {
+				t.Errorf("sections %q, want %q", sections, test.sections)
+			}
+			paragraphs := make([]string, len(d.Paragraphs))
+			for i, p := range d.Paragraphs {
+				paragraphs[i] = p.Kind + " " + orNone(p.Old) + " " + orNone(p.New)
+			}
+			if !reflect.DeepEqual(paragraphs, test.paragraphs) {
+				t.Errorf("paragraphs %q, want %q", paragraphs, test.paragraphs)
+			}
+		})
+	}
+}
+
+func TestDiffMinSimilarity(t *testing.T) {
+	old, new := diffDraft("A B C"), diffDraft("A B+ C")
+	kinds := func(d *ManuscriptDiff) []string {
+		l := make([]string, len(d.Paragraphs))
+		for i, p := range d.Paragraphs {
+			l[i] = p.Kind
+		}
+		return l
+	}
+	if got := kinds(Diff(old, new, 0.5)); !reflect.DeepEqual(got, []string{ChangeRewritten}) {
+		t.Errorf("at 0.5, %v", got)
+	}
+	if got := kinds(Diff(old, new, 0.95)); !reflect.DeepEqual(got, []string{ChangeAdded, ChangeRemoved}) {
+		t.Errorf("at 0.95, %v", got)
+	}
+}
@@ -0,0 +1,161 @@
+package booktools
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+)
+
+// redlineRow is one row of the side by side comparison: a paragraph of
+// the old draft beside what became of it in the new.
+type redlineRow struct {
+	Kind string
+	Note string
+	Old  string
+	New  string
+	// Words of the two sides, marked where they differ.
+	OldWords []redlineWord
+	NewWords []redlineWord
+}
+
+type redlineWord struct {
+	Word    string
+	Changed bool
+}
+
+var redlineTemplate = template.Must(template.New("redline").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Changes</title>
+<style>
+body { font-family: Georgia, serif; margin: 2em; }
+table { border-collapse: collapse; width: 100%; }
+th, td { vertical-align: top; padding: 0.4em 0.6em; border-bottom: 1px solid #ddd; width: 45%; }
+td.address { width: 5%; color: #888; font-size: 0.8em; white-space: nowrap; }
+tr.added td.new, ins { background: #dfd; text-decoration: none; }
+tr.removed td.old, del { background: #fdd; }
+tr.moved td { background: #eef; }
+.note { color: #666; font-size: 0.8em; font-style: italic; }
+</style>
+</head>
+<body>
+<h1>Changes</h1>
+<p>Words: {{.Diff.OldWords}} → {{.Diff.NewWords}} ({{printf "%+d" .Words}})</p>
+<table>
+<tr><th>Chapter</th><th>Change</th><th>Words</th><th>Title</th></tr>
+{{range .Diff.Chapters}}<tr><td class="address">{{if .Old}}{{.Old}}{{else}}-{{end}} → {{if .New}}{{.New}}{{else}}-{{end}}</td><td>{{.Kind}}</td><td>{{printf "%+d" .Delta}}</td><td>{{.Title}}</td></tr>
+{{end}}</table>
+<h2>Text</h2>
+<table>
+<tr><th></th><th>Old</th><th></th><th>New</th></tr>
+{{range .Rows}}<tr class="{{.Kind}}"><td class="address">{{.Old}}</td><td class="old">{{range .OldWords}}{{if .Changed}}<del>{{.Word}}</del>{{else}}{{.Word}}{{end}} {{end}}</td><td class="address">{{.New}}</td><td class="new">{{range .NewWords}}{{if .Changed}}<ins>{{.Word}}</ins>{{else}}{{.Word}}{{end}} {{end}}{{if .Note}}<div class="note">{{.Note}}</div>{{end}}</td></tr>
+{{end}}</table>
+</body>
+</html>
+`))
+
+// WriteDiffHTML writes the drafts compared by d side by side, in the
+// order of the new draft, with removed and inserted words marked.
+func WriteDiffHTML(w io.Writer, d *ManuscriptDiff) error {
+	return redlineTemplate.Execute(w, struct {
+		Diff  *ManuscriptDiff
+		Words int
+		Rows  []redlineRow
+	}{d, d.NewWords - d.OldWords, d.redlineRows()})
+}
+
+func (d *ManuscriptDiff) redlineRows() []redlineRow {
+	rows := make([]redlineRow, 0)
+	plain := func(words []string) []redlineWord {
+		l := make([]redlineWord, len(words))
+		for i, w := range words {
+			l[i] = redlineWord{Word: w}
+		}
+		return l
+	}
+	next := 0
+	// leftOver adds the old paragraphs before upTo which were removed,
+	// or moved to somewhere else.
+	leftOver := func(upTo int) {
+		for ; next < upTo; next++ {
+			p := d.old[next]
+			j := d.oldTo[next]
+			if j >= 0 && d.inOrder[j] {
+				continue
+			}
+			row := redlineRow{Kind: ChangeRemoved, Old: p.address.String(), OldWords: plain(p.words)}
+			if j >= 0 {
+				row.Kind = ChangeMoved
+				row.Note = fmt.Sprintf("Moved to %v", d.new[j].address)
+			}
+			rows = append(rows, row)
+		}
+	}
+	for j, p := range d.new {
+		i := d.newTo[j]
+		if i < 0 {
+			rows = append(rows, redlineRow{Kind: ChangeAdded, New: p.address.String(), NewWords: plain(p.words)})
+			continue
+		}
+		if !d.inOrder[j] {
+			row := redlineRow{Kind: ChangeMoved, New: p.address.String(), Note: fmt.Sprintf("Moved from %v", d.old[i].address)}
+			_, row.NewWords = redline(d.old[i].words, p.words)
+			rows = append(rows, row)
+			continue
+		}
+		leftOver(i)
+		next = i + 1
+		row := redlineRow{Kind: ChangeUnchanged, Old: d.old[i].address.String(), New: p.address.String()}
+		if d.old[i].hash != p.hash {
+			row.Kind = ChangeRewritten
+		}
+		row.OldWords, row.NewWords = redline(d.old[i].words, p.words)
+		rows = append(rows, row)
+	}
+	leftOver(len(d.old))
+	return rows
+}
+
+// redline marks the words of old and new which are not in their longest
+// common sequence of words.
+func redline(old []string, new []string) ([]redlineWord, []redlineWord) {
+	// lcs[i][j] is the length of the longest common sequence of
+	// old[i:] and new[j:].
+	lcs := make([][]int, len(old)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(new)+1)
+	}
+	for i := len(old) - 1; i >= 0; i-- {
+		for j := len(new) - 1; j >= 0; j-- {
+			if old[i] == new[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+	o := make([]redlineWord, len(old))
+	n := make([]redlineWord, len(new))
+	for i := range old {
+		o[i] = redlineWord{Word: old[i], Changed: true}
+	}
+	for j := range new {
+		n[j] = redlineWord{Word: new[j], Changed: true}
+	}
+	for i, j := 0, 0; i < len(old) && j < len(new); {
+		switch {
+		case old[i] == new[j]:
+			o[i].Changed, n[j].Changed = false, false
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			i++
+		default:
+			j++
+		}
+	}
+	return o, n
+}
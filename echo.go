@@ -0,0 +1,116 @@
+package booktools
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+func init() {
+	RegisterAnalyzer(EchoAnalyzer{Window: 30, MinLength: 4, Stem: true})
+}
+
+// Echo is a word repeated too soon after itself, or a sentence which
+// opens with the same word as the one before it.
+type Echo struct {
+	Address  Address `json:"address" yaml:"address"`
+	Kind     string  `json:"kind" yaml:"kind"`
+	Word     string  `json:"word" yaml:"word"`
+	Distance int     `json:"distance" yaml:"distance"`
+
+	// First and Second are the repeated words.
+	First  *Chunk `json:"-" yaml:"-"`
+	Second *Chunk `json:"-" yaml:"-"`
+}
+
+func (e Echo) String() string {
+	if e.Kind == "opener" {
+		return fmt.Sprintf("consecutive sentences open with %v", e.Word)
+	}
+	return fmt.Sprintf("%v repeated after %d words", e.Word, e.Distance)
+}
+
+// EchoList lists echoes in reading order.
+type EchoList []Echo
+
+func (l EchoList) Columns() []string { return []string{"Address", "Kind", "Word", "Distance"} }
+
+func (l EchoList) Rows() [][]string {
+	rows := make([][]string, len(l))
+	for i, e := range l {
+		rows[i] = []string{e.Address.String(), e.Kind, e.Word, strconv.Itoa(e.Distance)}
+	}
+	return rows
+}
+
+func (l EchoList) String() string {
+	sb := strings.Builder{}
+	for _, e := range l {
+		sb.WriteString(fmt.Sprintf("%-10v %v\n", e.Address, e))
+	}
+	return sb.String()
+}
+
+// EchoAnalyzer finds words of at least MinLength letters, other than
+// StopWords and names, used twice within Window words of each other, comparing
+// stems if Stem is set. It also finds consecutive sentences within a
+// section which open with the same word.
+type EchoAnalyzer struct {
+	Window    int
+	MinLength int
+	Stem      bool
+}
+
+func (a EchoAnalyzer) Name() string { return "echoes" }
+func (a EchoAnalyzer) Description() string {
+	return "Finds words repeated close together and repeated sentence openers"
+}
+func (a EchoAnalyzer) Unit() int { return Sentence }
+
+func (a EchoAnalyzer) Analyze(c *Chunk) Result {
+	l := make(EchoList, 0)
+	// Names are repeated as a matter of course, so leave out any word
+	// which is capitalized other than at the start of a sentence.
+	_, names := nameIncidence(c)
+	last := make(map[string]int)
+	lastWord := make(map[string]*Chunk)
+	i := 0
+	chapter := 0
+	var prevSentence Address
+	prevOpener := ""
+	var prevOpenerWord *Chunk
+	c.Walk(Word, func(addr Address, w *Chunk) bool {
+		if addr.Chapter != chapter {
+			// Echoes are only looked for within a chapter
+			chapter = addr.Chapter
+			last = make(map[string]int)
+			lastWord = make(map[string]*Chunk)
+		}
+		i = i + 1
+		plain := WordKey(w.Word)
+		if addr != prevSentence {
+			// First word of a new sentence
+			sameSection := addr.Chapter == prevSentence.Chapter && addr.Section == prevSentence.Section
+			if sameSection && plain != "" && plain == prevOpener {
+				l = append(l, Echo{Address: addr, Kind: "opener", Word: plain, First: prevOpenerWord, Second: w})
+			}
+			prevSentence = addr
+			prevOpener = plain
+			prevOpenerWord = w
+		}
+		if len([]rune(plain)) < a.MinLength || StopWords[plain] || names[NormalizeWord(w.Word)] > 0 {
+			return true
+		}
+		key := plain
+		if a.Stem {
+			key = Stem(plain)
+		}
+		if j, ok := last[key]; ok && i-j <= a.Window {
+			l = append(l, Echo{Address: addr, Kind: "echo", Word: plain, Distance: i - j, First: lastWord[key], Second: w})
+		}
+		last[key] = i
+		lastWord[key] = w
+		return true
+	})
+	return l
+}
@@ -0,0 +1,161 @@
+package booktools
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// HistoryPoint is the state of a manuscript as of one commit.
+type HistoryPoint struct {
+	Commit       string    `json:"commit" yaml:"commit"`
+	Date         time.Time `json:"date" yaml:"date"`
+	Subject      string    `json:"subject" yaml:"subject"`
+	Words        int       `json:"words" yaml:"words"`
+	Chapters     int       `json:"chapters" yaml:"chapters"`
+	Characters   int       `json:"characters" yaml:"characters"`
+	ChapterWords []int     `json:"chapterWords" yaml:"chapterWords"`
+}
+
+// History is the series of revisions of a manuscript committed to git,
+// oldest first.
+type History struct {
+	File   string         `json:"file" yaml:"file"`
+	Points []HistoryPoint `json:"points" yaml:"points"`
+
+	chapterStart func(sentence string) bool
+	mu           sync.Mutex
+	known        map[string]HistoryPoint
+}
+
+// NewHistory makes the history of file, which must be in a git
+// repository, chunking each revision with chapterStart as Parse does.
+// It is empty until Update is called.
+func NewHistory(file string, chapterStart func(sentence string) bool) *History {
+	return &History{File: file, chapterStart: chapterStart, known: make(map[string]HistoryPoint)}
+}
+
+// Update reads the commits which changed the file, following it
+// through renames, using the git command. Commits which deleted it
+// have no revision to read, and are left out. Only revisions not seen
+// by an earlier Update are chunked.
+func (h *History) Update() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	abs, err := filepath.Abs(h.File)
+	if err != nil {
+		return err
+	}
+	dir, base := filepath.Dir(abs), filepath.Base(abs)
+	out, err := git(dir, "log", "--follow", "--diff-filter=d", "--name-only", "--format=%x00%H%x09%aI%x09%s", "--", base)
+	if err != nil {
+		return err
+	}
+	points := make([]HistoryPoint, 0)
+	for _, entry := range strings.Split(string(out), "\x00") {
+		lines := strings.Split(strings.TrimSpace(entry), "\n")
+		fields := strings.SplitN(lines[0], "\t", 3)
+		if len(fields) < 3 {
+			continue
+		}
+		// The path, relative to the top of the repository, follows
+		// after a blank line. Merges list none, and are skipped.
+		path := strings.TrimSpace(lines[len(lines)-1])
+		if len(lines) < 2 || path == "" {
+			continue
+		}
+		p, ok := h.known[fields[0]]
+		if !ok {
+			date, err := time.Parse(time.RFC3339, fields[1])
+			if err != nil {
+				return fmt.Errorf("commit %v has date %q: %v", fields[0], fields[1], err)
+			}
+			text, err := git(dir, "show", fields[0]+":"+path)
+			if err != nil {
+				return err
+			}
+			p = historyPoint(Parse(string(text), h.chapterStart))
+			p.Commit, p.Date, p.Subject = fields[0], date, fields[2]
+			h.known[p.Commit] = p
+		}
+		points = append(points, p)
+	}
+	// git lists the newest first.
+	for i, j := 0, len(points)-1; i < j; i, j = i+1, j-1 {
+		points[i], points[j] = points[j], points[i]
+	}
+	h.Points = points
+	return nil
+}
+
+func historyPoint(root *Chunk) HistoryPoint {
+	p := HistoryPoint{Words: root.GetWordCount(), Characters: len(CharacterFrequencies(root, 3, 1))}
+	for _, c := range root.Chapters() {
+		p.ChapterWords = append(p.ChapterWords, c.GetWordCount())
+	}
+	p.Chapters = len(p.ChapterWords)
+	return p
+}
+
+// git runs a git command in dir, returning its output.
+func git(dir string, args ...string) ([]byte, error) {
+	stderr := bytes.Buffer{}
+	cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("git %v: %v: %v", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return out, nil
+}
+
+// MaxChapters is the most chapters the manuscript has had.
+func (h *History) MaxChapters() int {
+	n := 0
+	for _, p := range h.Points {
+		if p.Chapters > n {
+			n = p.Chapters
+		}
+	}
+	return n
+}
+
+func (h *History) Columns() []string {
+	columns := []string{"Commit", "Date", "Subject", "Words", "Chapters", "Characters"}
+	for i := 1; i <= h.MaxChapters(); i++ {
+		columns = append(columns, "Chapter "+strconv.Itoa(i))
+	}
+	return columns
+}
+
+func (h *History) Rows() [][]string {
+	n := h.MaxChapters()
+	rows := make([][]string, len(h.Points))
+	for i, p := range h.Points {
+		row := []string{p.Commit, p.Date.Format(time.RFC3339), p.Subject, strconv.Itoa(p.Words), strconv.Itoa(p.Chapters), strconv.Itoa(p.Characters)}
+		for c := 0; c < n; c++ {
+			if c < len(p.ChapterWords) {
+				row = append(row, strconv.Itoa(p.ChapterWords[c]))
+			} else {
+				row = append(row, "")
+			}
+		}
+		rows[i] = row
+	}
+	return rows
+}
+
+func (h *History) String() string {
+	sb := strings.Builder{}
+	last := 0
+	for _, p := range h.Points {
+		sb.WriteString(fmt.Sprintf("%v %.7v %7d %+7d %3d chapters %3d characters  %v\n", p.Date.Format("2006-01-02"), p.Commit, p.Words, p.Words-last, p.Chapters, p.Characters, p.Subject))
+		last = p.Words
+	}
+	return sb.String()
+}
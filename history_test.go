@@ -0,0 +1,71 @@
+package booktools
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestHistory(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	dir := t.TempDir()
+	run := func(args ...string) {
+		cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+		cmd.Env = append(os.Environ(),
+			"GIT_AUTHOR_NAME=Author", "GIT_AUTHOR_EMAIL=author@example.com",
+			"GIT_COMMITTER_NAME=Author", "GIT_COMMITTER_EMAIL=author@example.com",
+			"GIT_CONFIG_NOSYSTEM=1", "HOME="+dir)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v: %s", args, err, out)
+		}
+	}
+	write := func(name string, text string) {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(text), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	run("init", "-q")
+	write("draft.txt", "Chapter 1\n\nAnna slept.\n")
+	run("add", "draft.txt")
+	run("commit", "-q", "-m", "Start")
+	write("draft.txt", "Chapter 1\n\nAnna slept late.\n")
+	run("commit", "-q", "-a", "-m", "Revise")
+	run("mv", "draft.txt", "book.txt")
+	run("commit", "-q", "-m", "Rename")
+	run("rm", "-q", "book.txt")
+	run("commit", "-q", "-m", "Delete")
+	write("book.txt", "Chapter 1\n\nAnna slept late.\n\nChapter 2\n\nBen woke.\n")
+	run("add", "book.txt")
+	run("commit", "-q", "-m", "Restore")
+
+	h := NewHistory(filepath.Join(dir, "book.txt"), nil)
+	if err := h.Update(); err != nil {
+		t.Fatal(err)
+	}
+	subjects, words := make([]string, len(h.Points)), make([]int, len(h.Points))
+	for i, p := range h.Points {
+		subjects[i], words[i] = p.Subject, p.Words
+	}
+	if want := []string{"Start", "Revise", "Rename", "Restore"}; !reflect.DeepEqual(subjects, want) {
+		t.Errorf("subjects %q, want %q", subjects, want)
+	}
+	if want := []int{4, 5, 5, 9}; !reflect.DeepEqual(words, want) {
+		t.Errorf("words %v, want %v", words, want)
+	}
+	if n := h.Points[len(h.Points)-1].Chapters; n != 2 {
+		t.Errorf("%d chapters at the last commit, want 2", n)
+	}
+	// A second Update finds nothing new and keeps the same points.
+	before := h.Points
+	if err := h.Update(); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(h.Points, before) {
+		t.Errorf("points changed on a second update")
+	}
+}
@@ -0,0 +1,280 @@
+package booktools
+
+import (
+	"io"
+	"io/ioutil"
+	"strings"
+)
+
+// Document is the text of a manuscript together with its chunk tree,
+// kept up to date as the text is edited, and the results of analyses
+// run on it, kept until an edit makes them stale.
+type Document struct {
+	Text string
+	Root *Chunk
+
+	chapterStart func(sentence string) bool
+	cache        map[cacheKey]Result
+}
+
+// cacheKey names an analysis of one chapter, or of the whole work where
+// chapter is 0.
+type cacheKey struct {
+	analyzer string
+	chapter  int
+}
+
+// NewDocument parses text into a Document, starting chapters as Parse
+// does.
+func NewDocument(text string, chapterStart func(sentence string) bool) *Document {
+	if chapterStart == nil {
+		chapterStart = DefaultChapterStart
+	}
+	return &Document{Text: text, Root: Parse(text, chapterStart), chapterStart: chapterStart, cache: make(map[cacheKey]Result)}
+}
+
+// Result returns the result of running a on the given chapter, counted
+// from 1, or on the whole work where chapter is 0, or nil where there
+// is no such chapter. Results are kept until an edit touches the
+// chapter; those of the whole work until any edit.
+func (d *Document) Result(a Analyzer, chapter int) Result {
+	k := cacheKey{analyzer: a.Name(), chapter: chapter}
+	if r, ok := d.cache[k]; ok {
+		return r
+	}
+	c := d.Root
+	if chapter > 0 {
+		c = d.Root.GetChapterChunk(chapter)
+		if c == nil {
+			return nil
+		}
+	}
+	r := a.Analyze(c)
+	d.cache[k] = r
+	return r
+}
+
+// Edit replaces e.Length bytes of the text at e.Offset with e.Text.
+// Where the edit falls within the paragraphs of one section, and does
+// not start or end a paragraph, section or chapter, only those
+// paragraphs are reparsed and the chunks after them moved; otherwise
+// the whole text is parsed again. Edit reports whether it could avoid
+// the full parse.
+//
+// Either way the tree is the same as Parse would make of the new text.
+func (d *Document) Edit(e Edit) bool {
+	if e.Offset < 0 || e.Length < 0 || e.Offset+e.Length > len(d.Text) {
+		d.reparse(d.Text)
+		return false
+	}
+	text := d.Text[:e.Offset] + e.Text + d.Text[e.Offset+e.Length:]
+	chapter, ok := d.reparseParagraphs(text, e)
+	if !ok {
+		d.reparse(text)
+		return false
+	}
+	d.Text = text
+	for k := range d.cache {
+		if k.chapter == 0 || k.chapter == chapter {
+			delete(d.cache, k)
+		}
+	}
+	return true
+}
+
+func (d *Document) reparse(text string) {
+	d.Text = text
+	d.Root = Parse(text, d.chapterStart)
+	d.cache = make(map[cacheKey]Result)
+}
+
+// reparseParagraphs chunks the paragraphs touched by e in the new text,
+// carrying on from the state the chunker was in at the start of the
+// first of them, and splices them into the tree. It returns the chapter
+// they are in, or false where the edit changes more than those
+// paragraphs and a full parse is needed.
+func (d *Document) reparseParagraphs(text string, e Edit) (int, bool) {
+	chapter, section, first, last := d.Root.paragraphsAt(e.Offset, e.Offset+e.Length)
+	if section == nil || first == 0 {
+		// The first paragraph of a section holds the heading or
+		// marker which started it.
+		return 0, false
+	}
+	start := section.Children[first]
+	end := section.Children[last]
+	oldEnd := int(end.Position + end.Length)
+	if e.Offset+e.Length > oldEnd-1 || len(start.Children) == 0 || len(start.Children[0].Children) == 0 {
+		return 0, false
+	}
+	delta := len(e.Text) - e.Length
+	regionStart := int(start.Position)
+	regionEnd := oldEnd + delta
+	if strings.Contains(text[regionStart:regionEnd], "<!--") {
+		// It may open a metadata block, which the chunker only
+		// looks for at the start of a section.
+		return 0, false
+	}
+
+	chunks := make(chan *Chunk, 10)
+	type digest struct {
+		paragraphs []*Chunk
+		ok         bool
+	}
+	done := make(chan digest)
+	go func() {
+		words, sentences, paragraphs := make([]*Chunk, 0), make([]*Chunk, 0), make([]*Chunk, 0)
+		ok := true
+		for ch := range chunks {
+			switch ch.Unit {
+			case Word:
+				words = append(words, ch)
+			case Sentence:
+				if len(words) > 0 {
+					ch.Children = words
+					words = make([]*Chunk, 0)
+					sentences = append(sentences, ch)
+				}
+			case Paragraph:
+				if len(sentences) > 0 {
+					ch.Children = sentences
+					sentences = make([]*Chunk, 0)
+					paragraphs = append(paragraphs, ch)
+				}
+			default:
+				ok = false
+			}
+		}
+		done <- digest{paragraphs, ok && len(words) == 0 && len(sentences) == 0}
+	}()
+
+	c := NewChunker(strings.NewReader(text[regionStart:regionEnd]), chunks)
+	c.position = int64(regionStart)
+	c.lastWord = start.Children[0].Children[0].Position
+	c.lastSentence = start.Position
+	c.lastParagraph = start.Position
+	c.lastSection = section.Position
+	c.lastChapter = chapter.Position
+	c.lastRune = rune(d.Text[regionStart-1])
+	startsChapter := false
+	c.OnBeforeSentence = func(c *Chunker, s string) {
+		if d.chapterStart(s) {
+			startsChapter = true
+		}
+	}
+	// A read error falls back to Parse, which reports it.
+	_, err := io.Copy(ioutil.Discard, c)
+	result := <-done
+	if err != nil || !result.ok || startsChapter || c.position != int64(regionEnd) || c.lastParagraph != int64(regionEnd) {
+		return 0, false
+	}
+
+	// The word after the region starts where the chunker left off,
+	// which may have moved by other than delta, unless a metadata
+	// block comes between them.
+	next := d.Root.wordAfter(end)
+	if next != nil && next.Position >= int64(oldEnd) {
+		next = nil
+	}
+	var nextEnd int64
+	if next != nil {
+		nextEnd = next.Position + next.Length + int64(delta)
+	}
+
+	children := make([]*Chunk, 0, len(section.Children)-(last-first+1)+len(result.paragraphs))
+	children = append(children, section.Children[:first]...)
+	children = append(children, result.paragraphs...)
+	children = append(children, section.Children[last+1:]...)
+	section.Children = children
+	d.Root.shift(int64(regionStart), int64(oldEnd), int64(delta), result.paragraphs, next)
+	if next != nil && next.Position >= 0 {
+		next.Position = c.lastWord
+		next.Length = nextEnd - c.lastWord
+	}
+
+	for i, ch := range d.Root.Chapters() {
+		if ch == chapter {
+			return i + 1, true
+		}
+	}
+	return 0, true
+}
+
+// paragraphsAt finds the first and last paragraphs of a section which
+// the bytes from start to end fall in. The section is nil where they
+// fall in more than one section, or outside any paragraph.
+func (c *Chunk) paragraphsAt(start, end int) (*Chunk, *Chunk, int, int) {
+	if end > start {
+		end = end - 1
+	}
+	contains := func(p *Chunk, off int) bool {
+		return p.Position >= 0 && int(p.Position) <= off && off < int(p.Position+p.Length)
+	}
+	for _, chapter := range c.Children {
+		for _, section := range chapter.Children {
+			for i, p := range section.Children {
+				if !contains(p, start) {
+					continue
+				}
+				for j := i; j < len(section.Children); j++ {
+					if contains(section.Children[j], end) {
+						return chapter, section, i, j
+					}
+				}
+				return nil, nil, 0, 0
+			}
+		}
+	}
+	return nil, nil, 0, 0
+}
+
+// wordAfter returns the first word after the paragraph p.
+func (c *Chunk) wordAfter(p *Chunk) *Chunk {
+	var next *Chunk
+	found := false
+	var walk func(ch *Chunk) bool
+	walk = func(ch *Chunk) bool {
+		if ch == p {
+			found = true
+			return true
+		}
+		if found && ch.Unit == Word {
+			next = ch
+			return false
+		}
+		for _, child := range ch.Children {
+			if !walk(child) {
+				return false
+			}
+		}
+		return true
+	}
+	walk(c)
+	return next
+}
+
+// shift moves the chunks after an edit of the bytes from start to end
+// by delta, and stretches those around it, leaving alone the new
+// chunks in replaced and the word skip.
+func (c *Chunk) shift(start, end, delta int64, replaced []*Chunk, skip *Chunk) {
+	skipped := make(map[*Chunk]bool)
+	for _, p := range replaced {
+		skipped[p] = true
+	}
+	var walk func(ch *Chunk)
+	walk = func(ch *Chunk) {
+		if skipped[ch] {
+			return
+		}
+		if ch != skip && ch.Position >= 0 && ch.Unit != Work {
+			if ch.Position >= end {
+				ch.Position = ch.Position + delta
+			} else if ch.Position+ch.Length > start {
+				ch.Length = ch.Length + delta
+			}
+		}
+		for _, child := range ch.Children {
+			walk(child)
+		}
+	}
+	walk(c)
+}
@@ -0,0 +1,212 @@
+package booktools
+
+import (
+	"fmt"
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const incrementalText = `Chapter 1
+
+The rain had not stopped for three days. Anna watched it from the window, counting the drops.
+
+“We should go,” said Tom. He did not move.
+
+---
+
+Later, the sky cleared. They walked to the river and sat on the bank.
+
+The water was high. It carried branches, and once a chair.
+
+Chapter 2
+
+Morning came slowly. Anna woke first and made the tea.
+
+Tom read the letter twice before he spoke. “It is from my brother.”
+
+She said nothing. The kettle sang.
+`
+
+// sameTree returns where the trees a and b first differ, or "" where
+// they are the same.
+func sameTree(a, b *Chunk, path string) string {
+	if a.Unit != b.Unit || a.Position != b.Position || a.Length != b.Length || a.Word != b.Word || len(a.Children) != len(b.Children) || !reflect.DeepEqual(a.Meta, b.Meta) {
+		return fmt.Sprintf("%v: got %d %d+%d %q (%d children) %v, want %d %d+%d %q (%d children) %v", path,
+			a.Unit, a.Position, a.Length, a.Word, len(a.Children), a.Meta,
+			b.Unit, b.Position, b.Length, b.Word, len(b.Children), b.Meta)
+	}
+	for i := range a.Children {
+		if s := sameTree(a.Children[i], b.Children[i], fmt.Sprintf("%v/%d", path, i)); s != "" {
+			return s
+		}
+	}
+	return ""
+}
+
+// lineEndings returns the text with LF line endings, and with CRLF.
+func lineEndings(text string) map[string]string {
+	return map[string]string{"LF": text, "CRLF": strings.Replace(text, "\n", "\r\n", -1)}
+}
+
+func TestDocumentEdit(t *testing.T) {
+	tests := []struct {
+		name string
+		// at is the text the edit starts at, and length how much of
+		// it is replaced, counting a line break as one byte.
+		at          string
+		length      int
+		text        string
+		incremental bool
+	}{
+		{"insert word", "three days", 0, "long ", true},
+		{"delete word", "three ", 6, "", true},
+		{"replace within sentence", "counting", 8, "watching", true},
+		{"add sentence", "He did not move.", 0, "Anna sighed. ", true},
+		{"join sentences", ". He did", 5, ", he did", true},
+		{"edit across paragraphs", "drops.", 10, "drops, and ", true},
+		{"split paragraph", "He did not move.", 0, "\n\n", true},
+		{"join paragraphs", "\n\nThe water", 2, " ", false},
+		{"edit across section marker", "move.", 12, "move.\n\nLater", false},
+		{"remove section marker", "---", 3, "", false},
+		{"edit across chapter boundary", "a chair.", 21, "a chair.\n\nMorning", false},
+		{"start chapter", "Tom read", 0, "Chapter 3\n\n", false},
+		{"remove chapter heading", "Chapter 2", 9, "", false},
+		{"edit after chapter heading", "came slowly", 11, "came late", true},
+		{"insert at start", "Chapter 1", 0, "Prologue\n\n", false},
+	}
+	for ending, text := range lineEndings(incrementalText) {
+		for _, test := range tests {
+			t.Run(ending+"/"+test.name, func(t *testing.T) {
+				at := strings.Index(incrementalText, test.at)
+				if at < 0 {
+					t.Fatalf("%q not in the text", test.at)
+				}
+				// Where the line breaks are CRLF, each before and
+				// within the edit is a byte longer.
+				offset, length, replacement := at, test.length, test.text
+				if ending == "CRLF" {
+					offset = offset + strings.Count(incrementalText[:at], "\n")
+					length = length + strings.Count(incrementalText[at:at+test.length], "\n")
+					replacement = strings.Replace(replacement, "\n", "\r\n", -1)
+				}
+				d := NewDocument(text, nil)
+				incremental := d.Edit(Edit{Offset: offset, Length: length, Text: replacement})
+				want := text[:offset] + replacement + text[offset+length:]
+				if d.Text != want {
+					t.Fatalf("text is %q, want %q", d.Text, want)
+				}
+				if s := sameTree(d.Root, Parse(want, nil), ""); s != "" {
+					t.Errorf("tree differs from a full parse at %v", s)
+				}
+				if incremental != test.incremental {
+					t.Errorf("incremental = %v, want %v", incremental, test.incremental)
+				}
+			})
+		}
+	}
+}
+
+func TestDocumentRandomEdits(t *testing.T) {
+	inserts := []string{"x", " ", "word", " new words. ", "\n", "\n\n", ". ", "—", "“Hi,” he said. ", "---", "\n\n---\n\n", "Chapter 9\n\n", "é", "\r\n", "", "...", "  ", "\n\n---\npov: Anna\n---\n\n", "<!--\npov: Tom\n-->\n", "<!-- booktools-disable-line -->", "\n<!-- booktools-disable TYPO001 -->\n"}
+	for ending, text := range lineEndings(strings.Repeat(incrementalText+"\n", 3)) {
+		rng := rand.New(rand.NewSource(1))
+		d := NewDocument(text, nil)
+		incremental := 0
+		for i := 0; i < 1000; i++ {
+			offset := rng.Intn(len(d.Text) + 1)
+			length := 0
+			if rng.Intn(2) == 0 {
+				length = rng.Intn(20)
+			}
+			if offset+length > len(d.Text) {
+				length = len(d.Text) - offset
+			}
+			e := Edit{Offset: offset, Length: length, Text: inserts[rng.Intn(len(inserts))]}
+			before := d.Text
+			if d.Edit(e) {
+				incremental++
+			}
+			if s := sameTree(d.Root, Parse(d.Text, nil), ""); s != "" {
+				t.Fatalf("%v: edit %d %+v of %q: tree differs from a full parse at %v", ending, i, e, before, s)
+			}
+			if len(d.Text) < len(text)/2 {
+				d = NewDocument(text, nil)
+			}
+		}
+		if incremental == 0 {
+			t.Errorf("%v: no edit avoided a full parse", ending)
+		}
+	}
+}
+
+func TestDocumentResult(t *testing.T) {
+	d := NewDocument(incrementalText, nil)
+	a := CharacterFrequencyAnalyzer{}
+	count := func() int {
+		for _, f := range d.Result(a, 0).(FrequencyList) {
+			if f.Name == "Tom" {
+				return f.Count
+			}
+		}
+		return 0
+	}
+	before := count()
+	if before == 0 {
+		t.Fatalf("Tom not found")
+	}
+	d.Edit(Edit{Offset: strings.Index(d.Text, "The kettle"), Text: "Tom nodded. "})
+	if n := count(); n != before+1 {
+		t.Errorf("after the edit Tom named %d times, want %d", n, before+1)
+	}
+}
+
+// countingAnalyzer counts the chunks it has analyzed.
+type countingAnalyzer struct {
+	ContentsAnalyzer
+	runs *int
+}
+
+func (a countingAnalyzer) Analyze(c *Chunk) Result {
+	*a.runs = *a.runs + 1
+	return a.ContentsAnalyzer.Analyze(c)
+}
+
+func TestDocumentResultCache(t *testing.T) {
+	d := NewDocument(incrementalText, nil)
+	runs := 0
+	a := countingAnalyzer{runs: &runs}
+	for chapter := 0; chapter <= 2; chapter++ {
+		d.Result(a, chapter)
+	}
+	if d.Result(a, 3) != nil {
+		t.Errorf("a result for a chapter which does not exist")
+	}
+	if runs != 3 {
+		t.Fatalf("%d runs, want 3", runs)
+	}
+
+	// An edit within chapter 2 leaves chapter 1's result.
+	if !d.Edit(Edit{Offset: strings.Index(d.Text, "Tom read"), Text: "Tom nodded. "}) {
+		t.Fatalf("edit was not incremental")
+	}
+	runs = 0
+	d.Result(a, 1)
+	if runs != 0 {
+		t.Errorf("chapter 1 analyzed again after an edit to chapter 2")
+	}
+	d.Result(a, 2)
+	d.Result(a, 0)
+	if runs != 2 {
+		t.Errorf("%d of chapter 2 and the work analyzed again, want 2", runs)
+	}
+
+	// An edit which needs a full parse drops everything.
+	d.Edit(Edit{Offset: strings.Index(d.Text, "Chapter 2"), Length: len("Chapter 2"), Text: "Interlude"})
+	runs = 0
+	d.Result(a, 1)
+	if runs != 1 {
+		t.Errorf("chapter 1 not analyzed again after a full parse")
+	}
+}
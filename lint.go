@@ -0,0 +1,363 @@
+package booktools
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Severities
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+	SeverityInfo    = "info"
+)
+
+// LintRule describes one of the checks made by Lint.
+type LintRule struct {
+	ID          string `json:"id" yaml:"id"`
+	Severity    string `json:"severity" yaml:"severity"`
+	Description string `json:"description" yaml:"description"`
+}
+
+// TypographyRules are the checks made by Lint.
+var TypographyRules = []LintRule{
+	{"TYPO001", SeverityWarning, "More than one space between words"},
+	{"TYPO002", SeverityWarning, "Straight and curly quotation marks mixed"},
+	{"TYPO003", SeverityError, "Quotation marks not matched within a paragraph"},
+	{"TYPO004", SeverityWarning, "Hyphen used as a dash"},
+	{"TYPO005", SeverityWarning, "Ellipses written more than one way"},
+	{"TYPO006", SeverityWarning, "Space before punctuation"},
+	{"TYPO007", SeverityInfo, "Paragraph does not end with closing punctuation"},
+}
+
+// Edit replaces Length bytes of the text at Offset.
+type Edit struct {
+	Offset int    `json:"offset" yaml:"offset"`
+	Length int    `json:"length" yaml:"length"`
+	Text   string `json:"text" yaml:"text"`
+}
+
+// LintFinding is a problem found in the source text, at a 1-based line
+// and column counted in characters, with the edit which would fix it
+// where one is known.
+type LintFinding struct {
+	File     string `json:"file,omitempty" yaml:"file,omitempty"`
+	Rule     string `json:"rule" yaml:"rule"`
+	Severity string `json:"severity" yaml:"severity"`
+	Line     int    `json:"line" yaml:"line"`
+	Column   int    `json:"column" yaml:"column"`
+	Message  string `json:"message" yaml:"message"`
+	Fix      *Edit  `json:"fix,omitempty" yaml:"fix,omitempty"`
+
+	offset int
+}
+
+// LintFindings are the findings in a text, in the order they appear.
+type LintFindings []LintFinding
+
+func (l LintFindings) Columns() []string {
+	return []string{"Line", "Column", "Rule", "Severity", "Message"}
+}
+
+func (l LintFindings) Rows() [][]string {
+	rows := make([][]string, len(l))
+	for i, f := range l {
+		rows[i] = []string{strconv.Itoa(f.Line), strconv.Itoa(f.Column), f.Rule, f.Severity, f.Message}
+	}
+	return rows
+}
+
+func (l LintFindings) String() string {
+	sb := strings.Builder{}
+	for _, f := range l {
+		if f.File != "" {
+			sb.WriteString(f.File + ":")
+		}
+		sb.WriteString(fmt.Sprintf("%d:%d: %v %v: %v\n", f.Line, f.Column, f.Severity, f.Rule, f.Message))
+	}
+	return sb.String()
+}
+
+// linter collects findings against a text, translating byte offsets
+// into lines and columns.
+type linter struct {
+	text       string
+	lineStarts []int
+	findings   LintFindings
+}
+
+func newLinter(text string) *linter {
+	l := &linter{text: text, lineStarts: []int{0}}
+	for i := 0; i < len(text); i++ {
+		if text[i] == '\n' {
+			l.lineStarts = append(l.lineStarts, i+1)
+		}
+	}
+	return l
+}
+
+// position returns the 1-based line and column of a byte offset.
+func (l *linter) position(offset int) (int, int) {
+	line := sort.Search(len(l.lineStarts), func(i int) bool { return l.lineStarts[i] > offset }) - 1
+	return line + 1, utf8.RuneCountInString(l.text[l.lineStarts[line]:offset]) + 1
+}
+
+func (l *linter) add(id string, offset int, message string, fix *Edit) {
+	l.report(id, DescribeRule(id).Severity, offset, message, fix)
+}
+
+func (l *linter) report(id string, severity string, offset int, message string, fix *Edit) {
+	line, column := l.position(offset)
+	l.findings = append(l.findings, LintFinding{Rule: id, Severity: severity, Line: line, Column: column, Message: message, Fix: fix, offset: offset})
+}
+
+// textRange is a part of the text, from start to end.
+type textRange struct {
+	start, end int
+}
+
+// paragraphs splits the text at blank lines, as the chunker does.
+func (l *linter) paragraphs() []textRange {
+	ps := make([]textRange, 0)
+	start := -1
+	for i, ls := range l.lineStarts {
+		le := len(l.text)
+		if i+1 < len(l.lineStarts) {
+			le = l.lineStarts[i+1]
+		}
+		blank := strings.TrimSpace(l.text[ls:le]) == ""
+		if blank && start >= 0 {
+			ps = append(ps, textRange{start, ls})
+			start = -1
+		} else if !blank && start < 0 {
+			start = ls
+		}
+	}
+	if start >= 0 {
+		ps = append(ps, textRange{start, len(l.text)})
+	}
+	return ps
+}
+
+// Lint checks the typography of a manuscript's source text against the
+// TypographyRules.
+func Lint(text string) LintFindings {
+	return (&RuleSet{}).Lint(text)
+}
+
+// typography makes the checks of the TypographyRules.
+func (l *linter) typography() {
+	l.spacing()
+	l.quoteStyle()
+	l.unmatchedQuotes()
+	l.dashes()
+	l.ellipses()
+	l.closingPunctuation()
+}
+
+// spacing finds runs of spaces between words, and spaces before
+// punctuation.
+func (l *linter) spacing() {
+	t := l.text
+	for i := 0; i < len(t); i++ {
+		if t[i] != ' ' {
+			continue
+		}
+		j := i
+		for j < len(t) && t[j] == ' ' {
+			j++
+		}
+		lineStart := i == 0 || t[i-1] == '\n'
+		switch {
+		case lineStart || j == len(t) || t[j] == '\n' || t[j] == '\r':
+			// Indentation and trailing space are not seen by readers
+		case strings.ContainsRune(",;:!?", rune(t[j])) || (t[j] == '.' && !strings.HasPrefix(t[j:], "...")):
+			l.add("TYPO006", i, fmt.Sprintf("Space before %q", t[j]), &Edit{Offset: i, Length: j - i})
+		case j-i > 1:
+			l.add("TYPO001", i, fmt.Sprintf("%d spaces between words", j-i), &Edit{Offset: i, Length: j - i, Text: " "})
+		}
+		i = j - 1
+	}
+}
+
+// opensQuote reports whether a quotation mark at offset i opens a
+// quotation, judging by what comes before it.
+func (l *linter) opensQuote(i int) bool {
+	if i == 0 {
+		return true
+	}
+	r, _ := utf8.DecodeLastRuneInString(l.text[:i])
+	return unicode.IsSpace(r) || strings.ContainsRune("([{—–-", r)
+}
+
+// quoteStyle flags the quotation marks written in the less used of the
+// straight and curly styles, and fixes them to the more used. Single
+// quotes are left alone, as they are mostly apostrophes.
+func (l *linter) quoteStyle() {
+	straight, curly := make([]int, 0), make([]int, 0)
+	for i, r := range l.text {
+		switch r {
+		case '"':
+			straight = append(straight, i)
+		case '“', '”':
+			curly = append(curly, i)
+		}
+	}
+	if len(straight) == 0 || len(curly) == 0 {
+		return
+	}
+	if len(straight) >= len(curly) {
+		for _, i := range curly {
+			l.add("TYPO002", i, "Curly quotation mark in a manuscript using straight ones", &Edit{Offset: i, Length: len("“"), Text: "\""})
+		}
+		return
+	}
+	for _, i := range straight {
+		fix := "”"
+		if l.opensQuote(i) {
+			fix = "“"
+		}
+		l.add("TYPO002", i, "Straight quotation mark in a manuscript using curly ones", &Edit{Offset: i, Length: 1, Text: fix})
+	}
+}
+
+// unmatchedQuotes flags paragraphs whose double quotation marks do not
+// pair up. A quotation left open is allowed where the next paragraph
+// opens another, as when one speaker continues over several paragraphs.
+func (l *linter) unmatchedQuotes() {
+	ps := l.paragraphs()
+	for n, p := range ps {
+		open := -1
+		depth := 0
+		for i, r := range l.text[p.start:p.end] {
+			i = i + p.start
+			opening := r == '“' || (r == '"' && l.opensQuote(i))
+			closing := r == '”' || (r == '"' && !opening)
+			switch {
+			case opening && depth > 0:
+				l.add("TYPO003", open, "Quotation opened here is not closed before the next opens", nil)
+				open = i
+			case opening:
+				open = i
+				depth = 1
+			case closing && depth == 0:
+				l.add("TYPO003", i, "Closing quotation mark without an opening one", nil)
+			case closing:
+				depth = 0
+			}
+		}
+		if depth > 0 {
+			continues := false
+			if n+1 < len(ps) {
+				next := strings.TrimSpace(l.text[ps[n+1].start:ps[n+1].end])
+				continues = strings.HasPrefix(next, "\"") || strings.HasPrefix(next, "“")
+			}
+			if !continues {
+				l.add("TYPO003", open, "Quotation is not closed by the end of the paragraph", nil)
+			}
+		}
+	}
+}
+
+// dashes flags double hyphens and hyphens with space either side,
+// which were probably meant as dashes. A line of three hyphens is a
+// section marker, and is left alone.
+func (l *linter) dashes() {
+	t := l.text
+	for i := 0; i < len(t); i++ {
+		if t[i] != '-' {
+			continue
+		}
+		j := i
+		for j < len(t) && t[j] == '-' {
+			j++
+		}
+		switch {
+		case strings.HasSuffix(t[:i], "<!") || strings.HasPrefix(t[j:], ">"):
+			// Part of a comment
+		case j-i == 2:
+			l.add("TYPO004", i, "Double hyphen used as a dash", &Edit{Offset: i, Length: 2, Text: "—"})
+		case j-i == 1 && i > 0 && j < len(t) && t[i-1] == ' ' && t[j] == ' ':
+			l.add("TYPO004", i, "Spaced hyphen used as a dash", &Edit{Offset: i, Length: 1, Text: "—"})
+		}
+		i = j - 1
+	}
+}
+
+// ellipses flags the ellipses written in the less used of three dots
+// and the single ellipsis character.
+func (l *linter) ellipses() {
+	dots, chars := make([]int, 0), make([]int, 0)
+	for i := 0; i < len(l.text); i++ {
+		switch {
+		case strings.HasPrefix(l.text[i:], "..."):
+			dots = append(dots, i)
+			for i < len(l.text) && l.text[i] == '.' {
+				i++
+			}
+		case strings.HasPrefix(l.text[i:], "…"):
+			chars = append(chars, i)
+		}
+	}
+	if len(dots) == 0 || len(chars) == 0 {
+		return
+	}
+	if len(dots) >= len(chars) {
+		for _, i := range chars {
+			l.add("TYPO005", i, "Ellipsis character in a manuscript using three dots", &Edit{Offset: i, Length: len("…"), Text: "..."})
+		}
+		return
+	}
+	for _, i := range dots {
+		l.add("TYPO005", i, "Three dots in a manuscript using the ellipsis character", &Edit{Offset: i, Length: 3, Text: "…"})
+	}
+}
+
+// closingPunctuation flags paragraphs which do not end as a sentence
+// does. Headings, section markers, metadata blocks and other paragraphs
+// of fewer than four words are not expected to.
+func (l *linter) closingPunctuation() {
+	for _, p := range l.paragraphs() {
+		text := strings.TrimRightFunc(l.text[p.start:p.end], unicode.IsSpace)
+		if loc := trailingComment.FindStringIndex(text); loc != nil {
+			text = strings.TrimRightFunc(text[:loc[0]], unicode.IsSpace)
+		}
+		if len(strings.Fields(text)) < 4 || strings.HasPrefix(text, "#") || strings.HasPrefix(text, "---") {
+			continue
+		}
+		r, size := utf8.DecodeLastRuneInString(text)
+		if !strings.ContainsRune(".!?\"”’')…—*_]", r) {
+			l.add("TYPO007", p.start+len(text)-size, "Paragraph does not end with closing punctuation", nil)
+		}
+	}
+}
+
+// ApplyFixes returns the text with the fixes of the findings applied.
+// Where two fixes overlap, only the first is applied.
+func ApplyFixes(text string, findings LintFindings) string {
+	edits := make([]Edit, 0, len(findings))
+	for _, f := range findings {
+		if f.Fix != nil {
+			edits = append(edits, *f.Fix)
+		}
+	}
+	sort.SliceStable(edits, func(i, j int) bool {
+		return edits[i].Offset < edits[j].Offset
+	})
+	sb := strings.Builder{}
+	last := 0
+	for _, e := range edits {
+		if e.Offset < last {
+			continue
+		}
+		sb.WriteString(text[last:e.Offset])
+		sb.WriteString(e.Text)
+		last = e.Offset + e.Length
+	}
+	sb.WriteString(text[last:])
+	return sb.String()
+}
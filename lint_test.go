@@ -0,0 +1,148 @@
+package booktools
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+// findingKeys describes each finding as rule@line:column.
+func findingKeys(l LintFindings) []string {
+	keys := make([]string, len(l))
+	for i, f := range l {
+		keys[i] = fmt.Sprintf("%v@%d:%d", f.Rule, f.Line, f.Column)
+	}
+	return keys
+}
+
+func TestLint(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		findings []string
+		fixed    string
+	}{
+		{
+			"clean",
+			"Chapter 1\n\n“Come in,” she said. He did — slowly.\n",
+			[]string{},
+			"Chapter 1\n\n“Come in,” she said. He did — slowly.\n",
+		},
+		{
+			"double space",
+			"She sat  down by the fire.\n",
+			[]string{"TYPO001@1:8"},
+			"She sat down by the fire.\n",
+		},
+		{
+			"indentation and trailing space",
+			"    She sat down by the fire.   \n",
+			[]string{},
+			"    She sat down by the fire.   \n",
+		},
+		{
+			"space before punctuation",
+			"She sat down , and waited .\n",
+			[]string{"TYPO006@1:13", "TYPO006@1:26"},
+			"She sat down, and waited.\n",
+		},
+		{
+			"mixed quotes, curly more used",
+			"“Yes,” she said. “No,” he said. \"Why?\" she asked.\n",
+			[]string{"TYPO002@1:33", "TYPO002@1:38"},
+			"“Yes,” she said. “No,” he said. “Why?” she asked.\n",
+		},
+		{
+			"mixed quotes, straight more used",
+			"\"Yes,\" she said. \"No,\" he said. “Why?” she asked.\n",
+			[]string{"TYPO002@1:33", "TYPO002@1:38"},
+			"\"Yes,\" she said. \"No,\" he said. \"Why?\" she asked.\n",
+		},
+		{
+			"unclosed quotation",
+			"“Wait, she said and left the room.\n\nHe stayed where he was.\n",
+			[]string{"TYPO003@1:1"},
+			"“Wait, she said and left the room.\n\nHe stayed where he was.\n",
+		},
+		{
+			"quotation continued in the next paragraph",
+			"“It was late, and we were tired.\n\n“Then the bell rang,” she said.\n",
+			[]string{},
+			"“It was late, and we were tired.\n\n“Then the bell rang,” she said.\n",
+		},
+		{
+			"stray closing quotation",
+			"She said no.” Then she left.\n",
+			[]string{"TYPO003@1:13"},
+			"She said no.” Then she left.\n",
+		},
+		{
+			"hyphens as dashes",
+			"He waited -- then ran. She stopped - and stared.\n",
+			[]string{"TYPO004@1:11", "TYPO004@1:36"},
+			"He waited — then ran. She stopped — and stared.\n",
+		},
+		{
+			"section marker and comment",
+			"She slept.\n\n---\n\n<!-- a note -->\n\nHe woke.\n",
+			[]string{},
+			"She slept.\n\n---\n\n<!-- a note -->\n\nHe woke.\n",
+		},
+		{
+			"mixed ellipses",
+			"Well... perhaps... or… not.\n",
+			[]string{"TYPO005@1:22"},
+			"Well... perhaps... or... not.\n",
+		},
+		{
+			"no closing punctuation",
+			"Chapter 1\n\nShe looked out of the window\n\nHe left.\n",
+			[]string{"TYPO007@3:28"},
+			"Chapter 1\n\nShe looked out of the window\n\nHe left.\n",
+		},
+		{
+			"CRLF",
+			"Chapter 1\r\n\r\nShe sat  down.\r\n",
+			[]string{"TYPO001@3:8"},
+			"Chapter 1\r\n\r\nShe sat down.\r\n",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			l := Lint(test.text)
+			if keys := findingKeys(l); !reflect.DeepEqual(keys, test.findings) {
+				t.Errorf("findings %v, want %v", keys, test.findings)
+			}
+			if fixed := ApplyFixes(test.text, l); fixed != test.fixed {
+				t.Errorf("fixed %q, want %q", fixed, test.fixed)
+			}
+		})
+	}
+}
+
+func TestApplyFixes(t *testing.T) {
+	text := "abcdefghij"
+	tests := []struct {
+		name  string
+		edits []*Edit
+		want  string
+	}{
+		{"none", []*Edit{nil}, "abcdefghij"},
+		{"replace", []*Edit{{Offset: 2, Length: 3, Text: "X"}}, "abXfghij"},
+		{"insert", []*Edit{{Offset: 0, Text: "X"}}, "Xabcdefghij"},
+		{"delete at end", []*Edit{{Offset: 8, Length: 2}}, "abcdefgh"},
+		{"out of order", []*Edit{{Offset: 6, Length: 1, Text: "Y"}, {Offset: 1, Length: 1, Text: "X"}}, "aXcdefYhij"},
+		{"overlapping", []*Edit{{Offset: 1, Length: 4, Text: "X"}, {Offset: 3, Length: 1, Text: "Y"}}, "aXfghij"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			findings := make(LintFindings, len(test.edits))
+			for i, e := range test.edits {
+				findings[i].Fix = e
+			}
+			if got := ApplyFixes(text, findings); got != test.want {
+				t.Errorf("ApplyFixes = %q, want %q", got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,74 @@
+package booktools
+
+import (
+	"strings"
+	"unicode"
+)
+
+// NormalizeWord strips quotation marks, trailing contractions and
+// punctuation from a word, so that "Anna's", "Anna," and "Anna" are
+// all counted as the same word.
+func NormalizeWord(w string) string {
+	w = strings.Replace(w, "\"", "", -1)
+	w = strings.TrimSuffix(w, "'ve")
+	w = strings.TrimSuffix(w, "'re")
+	w = strings.TrimSuffix(w, "'d")
+	w = strings.TrimSuffix(w, "'ll")
+	w = strings.TrimSuffix(w, "'s")
+	w = strings.Replace(w, "'", "", -1)
+	w = strings.Replace(w, ",", "", -1)
+	w = strings.Replace(w, "?", "", -1)
+	w = strings.Replace(w, "!", "", -1)
+	w = strings.Replace(w, ".", "", -1)
+	return w
+}
+
+// diacritics maps accented Latin letters to the letter without its
+// accent.
+var diacritics = map[rune]rune{
+	'À': 'A', 'Á': 'A', 'Â': 'A', 'Ã': 'A', 'Ä': 'A', 'Å': 'A', 'Ā': 'A', 'Ă': 'A', 'Ą': 'A',
+	'à': 'a', 'á': 'a', 'â': 'a', 'ã': 'a', 'ä': 'a', 'å': 'a', 'ā': 'a', 'ă': 'a', 'ą': 'a',
+	'Ç': 'C', 'Ć': 'C', 'Č': 'C', 'ç': 'c', 'ć': 'c', 'č': 'c',
+	'Ď': 'D', 'ď': 'd', 'Đ': 'D', 'đ': 'd',
+	'È': 'E', 'É': 'E', 'Ê': 'E', 'Ë': 'E', 'Ē': 'E', 'Ė': 'E', 'Ę': 'E', 'Ě': 'E',
+	'è': 'e', 'é': 'e', 'ê': 'e', 'ë': 'e', 'ē': 'e', 'ė': 'e', 'ę': 'e', 'ě': 'e',
+	'Ğ': 'G', 'ğ': 'g',
+	'Ì': 'I', 'Í': 'I', 'Î': 'I', 'Ï': 'I', 'Ī': 'I', 'İ': 'I',
+	'ì': 'i', 'í': 'i', 'î': 'i', 'ï': 'i', 'ī': 'i', 'ı': 'i',
+	'Ł': 'L', 'ł': 'l', 'Ľ': 'L', 'ľ': 'l',
+	'Ñ': 'N', 'Ń': 'N', 'Ň': 'N', 'ñ': 'n', 'ń': 'n', 'ň': 'n',
+	'Ò': 'O', 'Ó': 'O', 'Ô': 'O', 'Õ': 'O', 'Ö': 'O', 'Ø': 'O', 'Ō': 'O', 'Ő': 'O',
+	'ò': 'o', 'ó': 'o', 'ô': 'o', 'õ': 'o', 'ö': 'o', 'ø': 'o', 'ō': 'o', 'ő': 'o',
+	'Ř': 'R', 'ř': 'r',
+	'Ś': 'S', 'Š': 'S', 'Ş': 'S', 'ś': 's', 'š': 's', 'ş': 's',
+	'Ť': 'T', 'ť': 't', 'Ţ': 'T', 'ţ': 't',
+	'Ù': 'U', 'Ú': 'U', 'Û': 'U', 'Ü': 'U', 'Ū': 'U', 'Ů': 'U', 'Ű': 'U',
+	'ù': 'u', 'ú': 'u', 'û': 'u', 'ü': 'u', 'ū': 'u', 'ů': 'u', 'ű': 'u',
+	'Ý': 'Y', 'Ÿ': 'Y', 'ý': 'y', 'ÿ': 'y',
+	'Ź': 'Z', 'Ż': 'Z', 'Ž': 'Z', 'ź': 'z', 'ż': 'z', 'ž': 'z',
+}
+
+// FoldDiacritics replaces accented Latin letters in s with the same
+// letter unaccented, so that "café" matches "cafe".
+func FoldDiacritics(s string) string {
+	return strings.Map(func(r rune) rune {
+		if f, ok := diacritics[r]; ok {
+			return f
+		}
+		return r
+	}, s)
+}
+
+// TrimPunctuation removes any leading or trailing characters from w
+// which are not letters or digits.
+func TrimPunctuation(w string) string {
+	return strings.TrimFunc(w, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// WordKey reduces a word to the lower case form, without punctuation or
+// contractions, in which words are counted and compared.
+func WordKey(w string) string {
+	return strings.ToLower(TrimPunctuation(NormalizeWord(strings.Replace(w, "’", "'", -1))))
+}
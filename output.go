@@ -0,0 +1,62 @@
+package booktools
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+// Formats lists the output formats understood by WriteResult.
+var Formats = []string{"text", "json", "csv", "tsv", "yaml", "sarif", "checkstyle"}
+
+// IsDiagnosticFormat reports whether format is one which only findings
+// with lines and columns, LintFindings, can be written in.
+func IsDiagnosticFormat(format string) bool {
+	return format == "sarif" || format == "checkstyle"
+}
+
+// WriteResult writes r to w in the named format. Text is the result's
+// own String(), json and yaml encode the result itself, and csv and tsv
+// write a header row of Columns() followed by Rows(). Sarif and
+// checkstyle are only for LintFindings; see Diagnostics for turning
+// other results into those.
+func WriteResult(w io.Writer, r Result, format string) error {
+	if IsDiagnosticFormat(format) {
+		findings, ok := r.(LintFindings)
+		if !ok {
+			return fmt.Errorf("output format %v is only for diagnostics such as lint, echoes and consistency", format)
+		}
+		if format == "sarif" {
+			return WriteSARIF(w, findings)
+		}
+		return WriteCheckstyle(w, findings)
+	}
+	switch format {
+	case "", "text":
+		_, err := io.WriteString(w, r.String())
+		return err
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(r)
+	case "yaml":
+		b, err := yaml.Marshal(r)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(b)
+		return err
+	case "csv", "tsv":
+		cw := csv.NewWriter(w)
+		if format == "tsv" {
+			cw.Comma = '\t'
+		}
+		cw.Write(r.Columns())
+		cw.WriteAll(r.Rows())
+		return cw.Error()
+	}
+	return fmt.Errorf("unknown output format %v", format)
+}
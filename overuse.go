@@ -0,0 +1,342 @@
+package booktools
+
+import (
+	"bufio"
+	_ "embed"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+func init() {
+	RegisterAnalyzer(OveruseAnalyzer{Baseline: EnglishBaseline(), MinCount: 5, MinRatio: 3})
+	RegisterAnalyzer(CrutchAnalyzer{Crutches: DefaultCrutches})
+}
+
+//go:embed english.txt
+var englishWords string
+
+// DefaultCrutches are words and phrases which often stand in for
+// stronger writing.
+var DefaultCrutches = []string{
+	"just", "suddenly", "very", "really", "somehow", "actually",
+	"began to", "started to", "seemed to", "couldn't help but",
+	"in order to", "at that moment", "all of a sudden",
+}
+
+// Baseline is a reference for how often words, and pairs of words,
+// would be expected to appear.
+type Baseline struct {
+	counts map[string]float64
+	total  float64
+	// phrases is set when counts holds pairs of words too.
+	phrases bool
+	// floor is the count assumed for a word not in counts, and
+	// unknownRatio how many times MinRatio such a word must exceed it
+	// by, where its count is only a guess.
+	floor        float64
+	unknownRatio float64
+}
+
+// EnglishBaseline estimates the frequency of common English words from
+// their rank in a built-in list, following Zipf's law. The list holds
+// only the most common few hundred words, so a word not in it is taken
+// to be as common as the first word after its end, which is as common
+// as it could be, and must be used twice as often again as a listed
+// word to be reported.
+func EnglishBaseline() *Baseline {
+	b := &Baseline{counts: make(map[string]float64), total: 1e9}
+	rank := 0
+	for _, line := range strings.Split(englishWords, "\n") {
+		w := strings.TrimSpace(line)
+		if w == "" || strings.HasPrefix(w, "#") {
+			continue
+		}
+		rank = rank + 1
+		if _, ok := b.counts[w]; !ok {
+			b.counts[w] = b.total * 0.1 / float64(rank)
+		}
+	}
+	b.floor = b.total * 0.1 / float64(rank+1)
+	b.unknownRatio = 2
+	return b
+}
+
+// BaselineFromCorpus counts the words, and pairs of words, of a reference
+// text such as an earlier book or a collection of the genre.
+func BaselineFromCorpus(r io.Reader) (*Baseline, error) {
+	b := &Baseline{counts: make(map[string]float64), phrases: true, floor: 0.5}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	prev := ""
+	for scanner.Scan() {
+		if strings.TrimSpace(scanner.Text()) == "" {
+			// Pairs do not span paragraphs
+			prev = ""
+		}
+		for _, field := range strings.Fields(scanner.Text()) {
+			k := WordKey(field)
+			if k == "" {
+				continue
+			}
+			b.counts[k] = b.counts[k] + 1
+			b.total = b.total + 1
+			if prev != "" {
+				b.counts[prev+" "+k] = b.counts[prev+" "+k] + 1
+			}
+			prev = k
+		}
+	}
+	return b, scanner.Err()
+}
+
+// BaselineFromList reads a frequency list of lines holding a word and
+// the number of times it appears, separated by whitespace.
+func BaselineFromList(r io.Reader) (*Baseline, error) {
+	b := &Baseline{counts: make(map[string]float64), floor: 0.5}
+	scanner := bufio.NewScanner(r)
+	line := 0
+	for scanner.Scan() {
+		line = line + 1
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("line %d: expected a word and a count", line)
+		}
+		n, err := strconv.ParseFloat(fields[1], 64)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", line, err)
+		}
+		k := WordKey(fields[0])
+		b.counts[k] = b.counts[k] + n
+		b.total = b.total + n
+	}
+	return b, scanner.Err()
+}
+
+// count returns the baseline count for key, or the floor if it is unknown.
+func (b *Baseline) count(key string) float64 {
+	if n, ok := b.counts[key]; ok {
+		return n
+	}
+	return b.floor
+}
+
+// minRatio returns how many times more often than the baseline key must
+// appear to be overused, given that a known word must appear ratio
+// times as often.
+func (b *Baseline) minRatio(key string, ratio float64) float64 {
+	if _, ok := b.counts[key]; !ok && b.unknownRatio > 1 {
+		return ratio * b.unknownRatio
+	}
+	return ratio
+}
+
+// OverusedWord is a word or phrase which appears more often than the
+// baseline would suggest.
+type OverusedWord struct {
+	Word           string  `json:"word" yaml:"word"`
+	Count          int     `json:"count" yaml:"count"`
+	Per10k         float64 `json:"per10k" yaml:"per10k"`
+	BaselinePer10k float64 `json:"baselinePer10k" yaml:"baselinePer10k"`
+	Ratio          float64 `json:"ratio" yaml:"ratio"`
+	// LogLikelihood measures how unlikely the count is given the
+	// baseline; above 10.83 the difference is significant at p < 0.001.
+	LogLikelihood float64 `json:"logLikelihood" yaml:"logLikelihood"`
+}
+
+// OverusedList lists overused words, most significant first.
+type OverusedList []OverusedWord
+
+func (l OverusedList) Columns() []string {
+	return []string{"Word", "Count", "Per10k", "BaselinePer10k", "Ratio", "LogLikelihood"}
+}
+
+func (l OverusedList) Rows() [][]string {
+	f := func(v float64) string { return strconv.FormatFloat(v, 'f', 2, 64) }
+	rows := make([][]string, len(l))
+	for i, o := range l {
+		rows[i] = []string{o.Word, strconv.Itoa(o.Count), f(o.Per10k), f(o.BaselinePer10k), f(o.Ratio), f(o.LogLikelihood)}
+	}
+	return rows
+}
+
+func (l OverusedList) String() string {
+	sb := strings.Builder{}
+	for _, o := range l {
+		sb.WriteString(fmt.Sprintf("%-20v %6d %8.1f/10k (expected %.1f, x%.1f)\n", o.Word, o.Count, o.Per10k, o.BaselinePer10k, o.Ratio))
+	}
+	return sb.String()
+}
+
+// OveruseAnalyzer finds words, other than names, which appear at least
+// MinCount times and MinRatio times more often than in the Baseline, and
+// significantly so. Pairs of words are compared too when the Baseline
+// was built from a corpus.
+type OveruseAnalyzer struct {
+	Baseline *Baseline
+	MinCount int
+	MinRatio float64
+}
+
+func (a OveruseAnalyzer) Name() string { return "overused" }
+func (a OveruseAnalyzer) Description() string {
+	return "Lists words used much more often than usual"
+}
+func (a OveruseAnalyzer) Unit() int { return Work }
+
+func (a OveruseAnalyzer) Analyze(c *Chunk) Result {
+	_, names := nameIncidence(c)
+	counts := make(map[string]int)
+	total := 0
+	c.Walk(Paragraph, func(addr Address, p *Chunk) bool {
+		words, _ := paragraphWords(p)
+		prev := ""
+		for _, w := range words {
+			k := WordKey(w.Word)
+			if k == "" {
+				continue
+			}
+			total = total + 1
+			if names[NormalizeWord(w.Word)] > 0 {
+				prev = ""
+				continue
+			}
+			counts[k] = counts[k] + 1
+			if a.Baseline.phrases && prev != "" {
+				counts[prev+" "+k] = counts[prev+" "+k] + 1
+			}
+			prev = k
+		}
+		return true
+	})
+
+	l := make(OverusedList, 0)
+	if total == 0 {
+		return l
+	}
+	for k, n := range counts {
+		if n < a.MinCount {
+			continue
+		}
+		expected := a.Baseline.count(k)
+		rate := float64(n) / float64(total)
+		baseRate := expected / a.Baseline.total
+		ratio := rate / baseRate
+		if ratio < a.Baseline.minRatio(k, a.MinRatio) {
+			continue
+		}
+		ll := logLikelihood(float64(n), float64(total), expected, a.Baseline.total)
+		if ll < 10.83 {
+			continue
+		}
+		l = append(l, OverusedWord{Word: k, Count: n, Per10k: rate * 10000, BaselinePer10k: baseRate * 10000, Ratio: ratio, LogLikelihood: ll})
+	}
+	sort.Slice(l, func(i, j int) bool { return l[i].LogLikelihood > l[j].LogLikelihood })
+	return l
+}
+
+// logLikelihood is Dunning's G² for a word seen a times in n words of
+// text and b times in m words of reference.
+func logLikelihood(a, n, b, m float64) float64 {
+	e1 := n * (a + b) / (n + m)
+	e2 := m * (a + b) / (n + m)
+	g := 0.0
+	if a > 0 {
+		g = g + a*math.Log(a/e1)
+	}
+	if b > 0 {
+		g = g + b*math.Log(b/e2)
+	}
+	return 2 * g
+}
+
+// CrutchCount is the use of one crutch word or phrase in each chapter.
+type CrutchCount struct {
+	Crutch   string  `json:"crutch" yaml:"crutch"`
+	Count    int     `json:"count" yaml:"count"`
+	Per10k   float64 `json:"per10k" yaml:"per10k"`
+	Chapters []int   `json:"chapters" yaml:"chapters"`
+}
+
+// CrutchList holds the count of every crutch.
+type CrutchList []CrutchCount
+
+func (l CrutchList) Columns() []string {
+	cols := []string{"Crutch", "Count", "Per10k"}
+	if len(l) > 0 {
+		for i := range l[0].Chapters {
+			cols = append(cols, fmt.Sprintf("Chapter%d", i+1))
+		}
+	}
+	return cols
+}
+
+func (l CrutchList) Rows() [][]string {
+	rows := make([][]string, len(l))
+	for i, c := range l {
+		row := []string{c.Crutch, strconv.Itoa(c.Count), strconv.FormatFloat(c.Per10k, 'f', 2, 64)}
+		for _, n := range c.Chapters {
+			row = append(row, strconv.Itoa(n))
+		}
+		rows[i] = row
+	}
+	return rows
+}
+
+func (l CrutchList) String() string {
+	sb := strings.Builder{}
+	for _, c := range l {
+		chapters := make([]string, len(c.Chapters))
+		for i, n := range c.Chapters {
+			chapters[i] = strconv.Itoa(n)
+		}
+		sb.WriteString(fmt.Sprintf("%-20v %6d %8.1f/10k  [%v]\n", c.Crutch, c.Count, c.Per10k, strings.Join(chapters, " ")))
+	}
+	return sb.String()
+}
+
+// CrutchAnalyzer counts each of the Crutches in every chapter.
+type CrutchAnalyzer struct {
+	Crutches []string
+}
+
+func (a CrutchAnalyzer) Name() string { return "crutches" }
+func (a CrutchAnalyzer) Description() string {
+	return "Counts crutch words and phrases in each chapter"
+}
+func (a CrutchAnalyzer) Unit() int { return Chapter }
+
+func (a CrutchAnalyzer) Analyze(c *Chunk) Result {
+	chapters := len(c.Chapters())
+	l := make(CrutchList, 0)
+	matchers := make([]*wordMatcher, 0)
+	for _, crutch := range a.Crutches {
+		m, err := newWordMatcher(crutch, SearchOptions{})
+		if err != nil {
+			continue
+		}
+		matchers = append(matchers, m)
+		l = append(l, CrutchCount{Crutch: crutch, Chapters: make([]int, chapters)})
+	}
+	c.Walk(Paragraph, func(addr Address, p *Chunk) bool {
+		words, _ := paragraphWords(p)
+		for i, m := range matchers {
+			n := len(m.match(words))
+			l[i].Count = l[i].Count + n
+			l[i].Chapters[addr.Chapter-1] = l[i].Chapters[addr.Chapter-1] + n
+		}
+		return true
+	})
+	if total := c.GetWordCount(); total > 0 {
+		for i := range l {
+			l[i].Per10k = float64(l[i].Count) * 10000 / float64(total)
+		}
+	}
+	return l
+}
@@ -0,0 +1,79 @@
+package booktools
+
+import (
+	"strings"
+	"testing"
+)
+
+// overuseText is a chapter of n sentences of common words, with each
+// of uses used as many times as given.
+func overuseText(n int, uses map[string]int) string {
+	sentences := make([]string, n)
+	for i := range sentences {
+		sentences[i] = "Then he said that it was time for them to go out with her, as they had not been there."
+	}
+	i := 0
+	for w, count := range uses {
+		for j := 0; j < count; j++ {
+			sentences[(i*7+j*13)%n] += " The " + w + " was there."
+		}
+		i = i + 1
+	}
+	return "Chapter 1\n\n" + strings.Join(sentences, "\n\n") + "\n"
+}
+
+func TestOveruseAnalyzer(t *testing.T) {
+	// About 3000 words
+	text := overuseText(150, map[string]int{"lantern": 5, "staircase": 40})
+	l := OveruseAnalyzer{Baseline: EnglishBaseline(), MinCount: 5, MinRatio: 3}.Analyze(Parse(text, nil)).(OverusedList)
+	found := make(map[string]bool)
+	for _, o := range l {
+		found[o.Word] = true
+	}
+	if found["lantern"] {
+		t.Errorf("a word not in the list used 5 times is reported: %v", l)
+	}
+	if !found["staircase"] {
+		t.Errorf("a word not in the list used 40 times is not reported: %v", l)
+	}
+	if found["the"] {
+		t.Errorf("the is reported: %v", l)
+	}
+}
+
+func TestBaselineMinRatio(t *testing.T) {
+	b := EnglishBaseline()
+	if r := b.minRatio("the", 3); r != 3 {
+		t.Errorf("minRatio(the) = %v, want 3", r)
+	}
+	if r := b.minRatio("staircase", 3); r != 6 {
+		t.Errorf("minRatio(staircase) = %v, want 6", r)
+	}
+	// "letter" is the last word of the list
+	if b.count("staircase") >= b.count("letter") || b.count("staircase") < b.count("letter")/2 {
+		t.Errorf("unknown words are counted as %v, where the last listed is %v", b.count("staircase"), b.count("letter"))
+	}
+	corpus, err := BaselineFromCorpus(strings.NewReader("the cat sat on the mat"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r := corpus.minRatio("staircase", 3); r != 3 {
+		t.Errorf("corpus minRatio(staircase) = %v, want 3", r)
+	}
+}
+
+func TestBaselineFromList(t *testing.T) {
+	b, err := BaselineFromList(strings.NewReader("# counts\nthe 100\nCat 10\n\ncat 5\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.total != 115 || b.count("cat") != 15 || b.count("dog") != b.floor {
+		t.Errorf("total %v, cat %v, dog %v", b.total, b.count("cat"), b.count("dog"))
+	}
+	if _, err := BaselineFromList(strings.NewReader("the\n")); err == nil {
+		t.Errorf("a line without a count is not an error")
+	}
+	if _, err := BaselineFromList(strings.NewReader("the many\n")); err == nil {
+		t.Errorf("a count which is not a number is not an error")
+	}
+}
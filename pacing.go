@@ -0,0 +1,203 @@
+package booktools
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+func init() {
+	RegisterAnalyzer(PacingAnalyzer{WallOfText: 300, RunLength: 4, RunTolerance: 2})
+}
+
+// Distribution summarises a list of lengths.
+type Distribution struct {
+	Count    int     `json:"count" yaml:"count"`
+	Min      int     `json:"min" yaml:"min"`
+	Max      int     `json:"max" yaml:"max"`
+	Mean     float64 `json:"mean" yaml:"mean"`
+	Median   float64 `json:"median" yaml:"median"`
+	Variance float64 `json:"variance" yaml:"variance"`
+	P10      float64 `json:"p10" yaml:"p10"`
+	P25      float64 `json:"p25" yaml:"p25"`
+	P75      float64 `json:"p75" yaml:"p75"`
+	P90      float64 `json:"p90" yaml:"p90"`
+}
+
+// NewDistribution summarises values.
+func NewDistribution(values []int) Distribution {
+	d := Distribution{Count: len(values)}
+	if len(values) == 0 {
+		return d
+	}
+	sorted := append([]int(nil), values...)
+	sort.Ints(sorted)
+	d.Min = sorted[0]
+	d.Max = sorted[len(sorted)-1]
+	sum := 0
+	for _, v := range sorted {
+		sum = sum + v
+	}
+	d.Mean = float64(sum) / float64(len(sorted))
+	for _, v := range sorted {
+		d.Variance = d.Variance + (float64(v)-d.Mean)*(float64(v)-d.Mean)
+	}
+	d.Variance = d.Variance / float64(len(sorted))
+	d.Median = percentile(sorted, 50)
+	d.P10 = percentile(sorted, 10)
+	d.P25 = percentile(sorted, 25)
+	d.P75 = percentile(sorted, 75)
+	d.P90 = percentile(sorted, 90)
+	return d
+}
+
+// percentile interpolates the pth percentile of the sorted values.
+func percentile(sorted []int, p float64) float64 {
+	rank := p / 100 * float64(len(sorted)-1)
+	lo := int(math.Floor(rank))
+	hi := int(math.Ceil(rank))
+	return float64(sorted[lo]) + (rank-float64(lo))*float64(sorted[hi]-sorted[lo])
+}
+
+// PacingFlag marks a passage whose rhythm may need attention: a
+// "wall" is a paragraph of more than WallOfText words, and a "run" is a
+// series of sentences of nearly the same length.
+type PacingFlag struct {
+	Address Address `json:"address" yaml:"address"`
+	Kind    string  `json:"kind" yaml:"kind"`
+	Message string  `json:"message" yaml:"message"`
+}
+
+// ChapterPacing holds the sentence and paragraph lengths, in words, of
+// one chapter.
+type ChapterPacing struct {
+	Chapter          int          `json:"chapter" yaml:"chapter"`
+	Sentences        Distribution `json:"sentences" yaml:"sentences"`
+	Paragraphs       Distribution `json:"paragraphs" yaml:"paragraphs"`
+	SentenceLengths  []int        `json:"sentenceLengths" yaml:"sentenceLengths"`
+	ParagraphLengths []int        `json:"paragraphLengths" yaml:"paragraphLengths"`
+	Flags            []PacingFlag `json:"flags" yaml:"flags"`
+}
+
+// PacingReport holds the pacing of every chapter.
+type PacingReport []ChapterPacing
+
+func (l PacingReport) Columns() []string {
+	return []string{"Chapter",
+		"Sentences", "SentenceMean", "SentenceMedian", "SentenceVariance", "SentenceP10", "SentenceP90", "SentenceMax",
+		"Paragraphs", "ParagraphMean", "ParagraphMedian", "ParagraphVariance", "ParagraphP10", "ParagraphP90", "ParagraphMax",
+		"Walls", "Runs"}
+}
+
+func (l PacingReport) Rows() [][]string {
+	f := func(v float64) string { return strconv.FormatFloat(v, 'f', 1, 64) }
+	dist := func(d Distribution) []string {
+		return []string{strconv.Itoa(d.Count), f(d.Mean), f(d.Median), f(d.Variance), f(d.P10), f(d.P90), strconv.Itoa(d.Max)}
+	}
+	rows := make([][]string, len(l))
+	for i, p := range l {
+		row := []string{strconv.Itoa(p.Chapter)}
+		row = append(row, dist(p.Sentences)...)
+		row = append(row, dist(p.Paragraphs)...)
+		rows[i] = append(row, strconv.Itoa(p.countFlags("wall")), strconv.Itoa(p.countFlags("run")))
+	}
+	return rows
+}
+
+func (l PacingReport) String() string {
+	sb := strings.Builder{}
+	for _, p := range l {
+		sb.WriteString(fmt.Sprintf("%03d: sentences %d, mean %.1f, median %.1f, sd %.1f, p10-p90 %.0f-%.0f; paragraphs %d, mean %.1f, median %.1f, sd %.1f, max %d\n",
+			p.Chapter, p.Sentences.Count, p.Sentences.Mean, p.Sentences.Median, math.Sqrt(p.Sentences.Variance), p.Sentences.P10, p.Sentences.P90,
+			p.Paragraphs.Count, p.Paragraphs.Mean, p.Paragraphs.Median, math.Sqrt(p.Paragraphs.Variance), p.Paragraphs.Max))
+		for _, flag := range p.Flags {
+			sb.WriteString(fmt.Sprintf("     %-10v %v\n", flag.Address, flag.Message))
+		}
+	}
+	return sb.String()
+}
+
+func (p ChapterPacing) countFlags(kind string) int {
+	n := 0
+	for _, f := range p.Flags {
+		if f.Kind == kind {
+			n = n + 1
+		}
+	}
+	return n
+}
+
+// PacingAnalyzer measures sentence and paragraph lengths per chapter,
+// flagging paragraphs longer than WallOfText words and runs of at least
+// RunLength sentences within RunTolerance words of the same length.
+type PacingAnalyzer struct {
+	WallOfText   int
+	RunLength    int
+	RunTolerance int
+}
+
+func (a PacingAnalyzer) Name() string { return "pacing" }
+func (a PacingAnalyzer) Description() string {
+	return "Measures sentence and paragraph lengths in each chapter"
+}
+func (a PacingAnalyzer) Unit() int { return Chapter }
+
+func (a PacingAnalyzer) Analyze(c *Chunk) Result {
+	l := make(PacingReport, 0)
+	for i := range c.Chapters() {
+		l = append(l, ChapterPacing{Chapter: i + 1, SentenceLengths: make([]int, 0), ParagraphLengths: make([]int, 0), Flags: make([]PacingFlag, 0)})
+	}
+	var runStart Address
+	runFirst, runCount := 0, 0
+	endRun := func() {
+		if a.RunLength > 1 && runCount >= a.RunLength {
+			p := &l[runStart.Chapter-1]
+			p.Flags = append(p.Flags, PacingFlag{Address: runStart, Kind: "run",
+				Message: fmt.Sprintf("%d sentences in a row of about %d words", runCount, runFirst)})
+		}
+		runCount = 0
+	}
+	c.Walk(Paragraph, func(pa Address, paragraph *Chunk) bool {
+		p := &l[pa.Chapter-1]
+		if pa.Chapter != runStart.Chapter {
+			endRun()
+		}
+		words := paragraph.GetWordCount()
+		p.ParagraphLengths = append(p.ParagraphLengths, words)
+		if a.WallOfText > 0 && words > a.WallOfText {
+			p.Flags = append(p.Flags, PacingFlag{Address: pa, Kind: "wall",
+				Message: fmt.Sprintf("paragraph of %d words", words)})
+		}
+		for j, sentence := range paragraph.Children {
+			n := len(sentence.Children)
+			p.SentenceLengths = append(p.SentenceLengths, n)
+			if runCount > 0 && abs(n-runFirst) <= a.RunTolerance {
+				runCount = runCount + 1
+				continue
+			}
+			endRun()
+			runStart = pa
+			runStart.Sentence = j + 1
+			runFirst, runCount = n, 1
+		}
+		return true
+	})
+	for i := range l {
+		l[i].Sentences = NewDistribution(l[i].SentenceLengths)
+		l[i].Paragraphs = NewDistribution(l[i].ParagraphLengths)
+	}
+	endRun()
+	for _, p := range l {
+		sort.SliceStable(p.Flags, func(i, j int) bool { return p.Flags[i].Address.Before(p.Flags[j].Address) })
+	}
+	return l
+}
+
+func abs(i int) int {
+	if i < 0 {
+		return -i
+	}
+	return i
+}
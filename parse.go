@@ -0,0 +1,54 @@
+package booktools
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+)
+
+// DefaultChapterStart reports whether a sentence starts a new chapter:
+// one beginning "Chapter ", a "# Part" heading, or a line ending in a
+// year used as a dateline.
+func DefaultChapterStart(s string) bool {
+	return strings.HasPrefix(strings.TrimSpace(s), "Chapter ") ||
+		strings.Contains(s, "# Part") || strings.Contains(s, "#Part") ||
+		strings.HasSuffix(s, "2011")
+}
+
+// Parse chunks the text of a manuscript into a Work. A new chapter is
+// started before each sentence for which chapterStart returns true, or
+// DefaultChapterStart where chapterStart is nil.
+func Parse(text string, chapterStart func(sentence string) bool) *Chunk {
+	if chapterStart == nil {
+		chapterStart = DefaultChapterStart
+	}
+	chunks := make(chan *Chunk, 10)
+	out := make(chan *Chunk)
+
+	chunker := NewChunker(strings.NewReader(text), chunks)
+	chunker.OnBeforeSentence = func(c *Chunker, s string) {
+		if chapterStart(s) {
+			c.Chapter()
+		}
+	}
+	go DigestChunks(chunks, out)
+	// The chunker does its work as it is read; what it reads is of no
+	// further use. It closes chunks however reading ends.
+	_, err := io.Copy(ioutil.Discard, chunker)
+	root := <-out
+	if err != nil {
+		// Reading a string cannot fail, so this is a bug in the
+		// chunker.
+		panic(fmt.Sprintf("booktools: parsing failed: %v", err))
+	}
+	if chunker.meta != nil {
+		// The last section is made by DigestChunks rather than the
+		// chunker, and so is given its metadata here.
+		sections := root.Find(Section)
+		if n := len(sections); n > 0 && sections[n-1].Position < 0 {
+			sections[n-1].Meta = chunker.meta
+		}
+	}
+	return root
+}
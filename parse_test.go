@@ -0,0 +1,42 @@
+package booktools
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestParseLongLine parses a paragraph on one line longer than the
+// 64 KB a bufio.Scanner allows, which once left Parse waiting forever.
+func TestParseLongLine(t *testing.T) {
+	long := strings.Repeat("She walked on and on. ", 5000)
+	text := "Chapter 1\n\n" + long + "\n\nHe waited.\n"
+	done := make(chan *Chunk)
+	go func() {
+		done <- Parse(text, nil)
+	}()
+	var root *Chunk
+	select {
+	case root = <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("Parse did not return")
+	}
+	if n, want := root.GetWordCount(), 2+5*5000+2; n != want {
+		t.Errorf("%d words, want %d", n, want)
+	}
+
+	d := NewDocument(text, nil)
+	offset := len("Chapter 1\n\n") + len(long)/2
+	go func() {
+		d.Edit(Edit{Offset: offset, Text: "Then "})
+		done <- d.Root
+	}()
+	select {
+	case root = <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("Edit did not return")
+	}
+	if n, want := root.GetWordCount(), 2+5*5000+3; n != want {
+		t.Errorf("after the edit, %d words, want %d", n, want)
+	}
+}
@@ -0,0 +1,324 @@
+package booktools
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ProgressFile is the name of the log of a manuscript's word counts,
+// kept beside its project file.
+const ProgressFile = ".booktools-progress.csv"
+
+func (t Targets) deadline() (time.Time, error) {
+	if t.Deadline == "" {
+		return time.Time{}, nil
+	}
+	d, err := time.ParseInLocation("2006-01-02", t.Deadline, time.Local)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("deadline %q is not a date such as 2027-03-01", t.Deadline)
+	}
+	return d, nil
+}
+
+// chapter returns the target for the chapter'th chapter of n, counting
+// from 1.
+func (t Targets) chapter(chapter int, n int) int {
+	if w, ok := t.Chapters[chapter]; ok {
+		return w
+	}
+	if t.ChapterWords > 0 {
+		return t.ChapterWords
+	}
+	if t.Words > 0 && n > 0 {
+		return t.Words / n
+	}
+	return 0
+}
+
+// ProgressEntry is the word count of a manuscript at one time.
+type ProgressEntry struct {
+	Time  time.Time
+	Words int
+}
+
+// ReadProgress reads a progress log, which is missing until the first
+// entry is appended.
+func ReadProgress(path string) ([]ProgressEntry, error) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	entries := make([]ProgressEntry, 0)
+	r := csv.NewReader(f)
+	r.FieldsPerRecord = 2
+	for {
+		record, err := r.Read()
+		if err == io.EOF {
+			return entries, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%v: %v", path, err)
+		}
+		t, err := time.Parse(time.RFC3339, record[0])
+		if err != nil {
+			return nil, fmt.Errorf("%v: %v", path, err)
+		}
+		words, err := strconv.Atoi(record[1])
+		if err != nil {
+			return nil, fmt.Errorf("%v: %v", path, err)
+		}
+		entries = append(entries, ProgressEntry{Time: t, Words: words})
+	}
+}
+
+// AppendProgress adds the word count at time t to the progress log,
+// unless it is the same as the last entry, made the same day.
+func AppendProgress(path string, words int, t time.Time) error {
+	entries, err := ReadProgress(path)
+	if err != nil {
+		return err
+	}
+	if n := len(entries); n > 0 && entries[n-1].Words == words && sameDay(entries[n-1].Time, t) {
+		return nil
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	w := csv.NewWriter(f)
+	w.Write([]string{t.Format(time.RFC3339), strconv.Itoa(words)})
+	w.Flush()
+	if err := w.Error(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func sameDay(a, b time.Time) bool {
+	a, b = a.Local(), b.Local()
+	return a.Year() == b.Year() && a.YearDay() == b.YearDay()
+}
+
+func day(t time.Time) time.Time {
+	t = t.Local()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.Local)
+}
+
+// DayProgress is the words written on one day, the change in the word
+// count from the day before.
+type DayProgress struct {
+	Date    time.Time `json:"date" yaml:"date"`
+	Words   int       `json:"words" yaml:"words"`
+	Written int       `json:"written" yaml:"written"`
+}
+
+// ChapterProgress is a chapter's word count against its target.
+type ChapterProgress struct {
+	Chapter int    `json:"chapter" yaml:"chapter"`
+	Title   string `json:"title" yaml:"title"`
+	Words   int    `json:"words" yaml:"words"`
+	Target  int    `json:"target,omitempty" yaml:"target,omitempty"`
+}
+
+// Percent is how much of its target the chapter has reached.
+func (c ChapterProgress) Percent() int { return percent(c.Words, c.Target) }
+
+func percent(n int, of int) int {
+	if of <= 0 {
+		return 0
+	}
+	return n * 100 / of
+}
+
+// ProgressReport is a manuscript's progress towards its targets.
+type ProgressReport struct {
+	Words    int       `json:"words" yaml:"words"`
+	Target   int       `json:"target,omitempty" yaml:"target,omitempty"`
+	Deadline time.Time `json:"deadline,omitempty" yaml:"deadline,omitempty"`
+	// Days are the days from the first entry of the log to today.
+	Days []DayProgress `json:"days" yaml:"days"`
+	// Streak is the days in a row up to today, or yesterday, on which
+	// words were written.
+	Streak        int `json:"streak" yaml:"streak"`
+	LongestStreak int `json:"longestStreak" yaml:"longestStreak"`
+	// Pace is the words written a day over the last PaceDays days, and
+	// Needed those needed a day to meet the deadline.
+	Pace      int               `json:"pace" yaml:"pace"`
+	PaceDays  int               `json:"paceDays" yaml:"paceDays"`
+	Needed    int               `json:"needed,omitempty" yaml:"needed,omitempty"`
+	Projected time.Time         `json:"projected,omitempty" yaml:"projected,omitempty"`
+	Chapters  []ChapterProgress `json:"chapters" yaml:"chapters"`
+}
+
+// PaceDays is how many days the pace of writing is averaged over.
+const PaceDays = 14
+
+// Progress measures the work root against targets, with the words
+// written each day found from the progress log entries, as of now.
+func Progress(root *Chunk, targets Targets, entries []ProgressEntry, now time.Time) *ProgressReport {
+	r := &ProgressReport{Words: root.GetWordCount(), Target: targets.Words, PaceDays: PaceDays}
+	r.Deadline, _ = targets.deadline()
+	chapters := root.Chapters()
+	for i, c := range chapters {
+		r.Chapters = append(r.Chapters, ChapterProgress{
+			Chapter: i + 1,
+			Title:   strings.TrimSpace(c.GetFirstSentence()),
+			Words:   c.GetWordCount(),
+			Target:  targets.chapter(i+1, len(chapters)),
+		})
+	}
+
+	today := day(now)
+	if len(entries) > 0 {
+		// The count at the end of each day, carried over days on
+		// which there is no entry.
+		last := make(map[time.Time]int)
+		for _, e := range entries {
+			last[day(e.Time)] = e.Words
+		}
+		words := entries[0].Words
+		previous := words
+		for d := day(entries[0].Time); !d.After(today); d = d.AddDate(0, 0, 1) {
+			if w, ok := last[d]; ok {
+				words = w
+			}
+			if d.Equal(today) {
+				words = r.Words
+			}
+			r.Days = append(r.Days, DayProgress{Date: d, Words: words, Written: words - previous})
+			previous = words
+		}
+	}
+
+	run := 0
+	for i, d := range r.Days {
+		if d.Written > 0 {
+			run++
+		} else {
+			run = 0
+		}
+		if run > r.LongestStreak {
+			r.LongestStreak = run
+		}
+		if i == len(r.Days)-1 || i == len(r.Days)-2 && d.Written > 0 {
+			if run > r.Streak {
+				r.Streak = run
+			}
+		}
+	}
+
+	written, days := 0, 0
+	for i := len(r.Days) - 1; i >= 0 && days < PaceDays; i-- {
+		written = written + r.Days[i].Written
+		days++
+	}
+	if days > 0 {
+		r.Pace = written / days
+	}
+	remaining := r.Target - r.Words
+	if remaining > 0 && r.Pace > 0 {
+		r.Projected = today.AddDate(0, 0, (remaining+r.Pace-1)/r.Pace)
+	}
+	if remaining > 0 && !r.Deadline.IsZero() {
+		left := int(r.Deadline.Sub(today).Hours()/24 + 0.5)
+		if left < 1 {
+			left = 1
+		}
+		r.Needed = (remaining + left - 1) / left
+	}
+	return r
+}
+
+// Percent is how much of its target the work has reached.
+func (r *ProgressReport) Percent() int { return percent(r.Words, r.Target) }
+
+// Recent returns the days of the last fortnight.
+func (r *ProgressReport) Recent() []DayProgress {
+	if len(r.Days) > PaceDays {
+		return r.Days[len(r.Days)-PaceDays:]
+	}
+	return r.Days
+}
+
+func (r *ProgressReport) Columns() []string {
+	return []string{"Date", "Words", "Written"}
+}
+
+func (r *ProgressReport) Rows() [][]string {
+	rows := make([][]string, len(r.Days))
+	for i, d := range r.Days {
+		rows[i] = []string{d.Date.Format("2006-01-02"), strconv.Itoa(d.Words), strconv.Itoa(d.Written)}
+	}
+	return rows
+}
+
+// bar draws n of max as a bar of width characters.
+func bar(n int, max int, width int) string {
+	filled := 0
+	if max > 0 {
+		filled = n * width / max
+	}
+	if filled > width {
+		filled = width
+	}
+	if filled < 0 {
+		filled = 0
+	}
+	return strings.Repeat("#", filled) + strings.Repeat(".", width-filled)
+}
+
+func (r *ProgressReport) String() string {
+	sb := strings.Builder{}
+	if r.Target > 0 {
+		sb.WriteString(fmt.Sprintf("Words:     %d of %d (%d%%)\n", r.Words, r.Target, r.Percent()))
+	} else {
+		sb.WriteString(fmt.Sprintf("Words:     %d\n", r.Words))
+	}
+	if !r.Deadline.IsZero() {
+		sb.WriteString(fmt.Sprintf("Deadline:  %v", r.Deadline.Format("2006-01-02")))
+		if r.Needed > 0 {
+			sb.WriteString(fmt.Sprintf(", %d words a day needed", r.Needed))
+		}
+		sb.WriteString("\n")
+	}
+	if len(r.Days) > 0 {
+		sb.WriteString(fmt.Sprintf("Pace:      %d words a day\n", r.Pace))
+	}
+	if !r.Projected.IsZero() {
+		sb.WriteString(fmt.Sprintf("Projected: %v\n", r.Projected.Format("2006-01-02")))
+	}
+	sb.WriteString(fmt.Sprintf("Streak:    %d days (longest %d)\n", r.Streak, r.LongestStreak))
+
+	if recent := r.Recent(); len(recent) > 0 {
+		most := 0
+		for _, d := range recent {
+			if d.Written > most {
+				most = d.Written
+			}
+		}
+		sb.WriteString("\nWritten\n")
+		for _, d := range recent {
+			sb.WriteString(fmt.Sprintf("  %v %+6d  %v\n", d.Date.Format("2006-01-02 Mon"), d.Written, strings.TrimRight(bar(d.Written, most, 30), ".")))
+		}
+	}
+
+	sb.WriteString("\nChapters\n")
+	for _, c := range r.Chapters {
+		if c.Target > 0 {
+			sb.WriteString(fmt.Sprintf("  %3d %-30v %6d / %-6d [%v] %d%%\n", c.Chapter, abbreviate(c.Title, 30), c.Words, c.Target, bar(c.Words, c.Target, 20), c.Percent()))
+		} else {
+			sb.WriteString(fmt.Sprintf("  %3d %-30v %6d\n", c.Chapter, abbreviate(c.Title, 30), c.Words))
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,156 @@
+package booktools
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// progressWork is a chapter of n words.
+func progressWork(n int) *Chunk {
+	return Parse("Chapter 1\n\n"+strings.Repeat("word ", n-2)+"\n", nil)
+}
+
+func progressDate(month time.Month, d int) time.Time {
+	return time.Date(2026, month, d, 12, 0, 0, 0, time.Local)
+}
+
+func TestProgress(t *testing.T) {
+	entries := []ProgressEntry{
+		{progressDate(3, 1), 1000},
+		{progressDate(3, 2), 1200},
+		{progressDate(3, 2).Add(time.Hour), 1500},
+		{progressDate(3, 5), 2000},
+		{progressDate(3, 8), 2600},
+		{progressDate(3, 9), 3000},
+	}
+	tests := []struct {
+		name          string
+		entries       []ProgressEntry
+		words         int
+		targets       Targets
+		written       []int
+		streak        int
+		longestStreak int
+		pace          int
+		needed        int
+		projected     time.Time
+	}{
+		{
+			"no log",
+			nil, 3200, Targets{Words: 10000},
+			nil, 0, 0, 0, 0, time.Time{},
+		},
+		{
+			"written today",
+			entries, 3200, Targets{Words: 10000, Deadline: "2026-04-09"},
+			[]int{0, 500, 0, 0, 500, 0, 0, 600, 400, 200}, 3, 3, 220, 227, progressDate(4, 10),
+		},
+		{
+			"nothing yet today",
+			entries, 3000, Targets{Words: 10000},
+			[]int{0, 500, 0, 0, 500, 0, 0, 600, 400, 0}, 2, 2, 200, 0, progressDate(4, 14),
+		},
+		{
+			"target reached",
+			entries, 3200, Targets{Words: 3000, Deadline: "2026-04-09"},
+			[]int{0, 500, 0, 0, 500, 0, 0, 600, 400, 200}, 3, 3, 220, 0, time.Time{},
+		},
+		{
+			"pace over the last fortnight",
+			[]ProgressEntry{{progressDate(2, 10), 1000}, {progressDate(2, 20), 2000}, {progressDate(3, 9), 3400}},
+			3400, Targets{},
+			nil, 1, 1, 100, 0, time.Time{},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := Progress(progressWork(test.words), test.targets, test.entries, progressDate(3, 10))
+			if test.written != nil {
+				written := make([]int, len(r.Days))
+				for i, d := range r.Days {
+					written[i] = d.Written
+				}
+				if !reflect.DeepEqual(written, test.written) {
+					t.Errorf("written %v, want %v", written, test.written)
+				}
+			}
+			if r.Streak != test.streak || r.LongestStreak != test.longestStreak {
+				t.Errorf("streak %d, longest %d, want %d, %d", r.Streak, r.LongestStreak, test.streak, test.longestStreak)
+			}
+			if r.Pace != test.pace || r.Needed != test.needed {
+				t.Errorf("pace %d, needed %d, want %d, %d", r.Pace, r.Needed, test.pace, test.needed)
+			}
+			if !r.Projected.Equal(day(test.projected)) && !(r.Projected.IsZero() && test.projected.IsZero()) {
+				t.Errorf("projected %v, want %v", r.Projected, day(test.projected))
+			}
+		})
+	}
+}
+
+func TestProgressChapters(t *testing.T) {
+	root := Parse("Chapter 1\n\nShe slept.\n\nChapter 2\n\nHe woke.\n\nChapter 3\n\nThey left.\n", nil)
+	tests := []struct {
+		targets Targets
+		want    []int
+	}{
+		{Targets{}, []int{0, 0, 0}},
+		{Targets{Words: 3000}, []int{1000, 1000, 1000}},
+		{Targets{Words: 3000, ChapterWords: 500}, []int{500, 500, 500}},
+		{Targets{Words: 3000, Chapters: map[int]int{2: 2000}}, []int{1000, 2000, 1000}},
+	}
+	for _, test := range tests {
+		r := Progress(root, test.targets, nil, progressDate(3, 10))
+		got := make([]int, len(r.Chapters))
+		for i, c := range r.Chapters {
+			got[i] = c.Target
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("targets %+v give chapter targets %v, want %v", test.targets, got, test.want)
+		}
+	}
+}
+
+func TestAppendProgress(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ProgressFile)
+	if entries, err := ReadProgress(path); err != nil || len(entries) != 0 {
+		t.Fatalf("missing log read as %v, %v", entries, err)
+	}
+	appends := []ProgressEntry{
+		{progressDate(3, 1), 1000},
+		{progressDate(3, 1).Add(time.Hour), 1000},
+		{progressDate(3, 1).Add(2 * time.Hour), 1100},
+		{progressDate(3, 2), 1100},
+	}
+	for _, e := range appends {
+		if err := AppendProgress(path, e.Words, e.Time); err != nil {
+			t.Fatal(err)
+		}
+	}
+	entries, err := ReadProgress(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []ProgressEntry{appends[0], appends[2], appends[3]}
+	if len(entries) != len(want) {
+		t.Fatalf("entries %v, want %v", entries, want)
+	}
+	for i := range want {
+		if !entries[i].Time.Equal(want[i].Time) || entries[i].Words != want[i].Words {
+			t.Errorf("entry %d is %v, want %v", i, entries[i], want[i])
+		}
+	}
+
+	bad := filepath.Join(t.TempDir(), ProgressFile)
+	for _, text := range []string{"2026-03-01T12:00:00Z\n", "yesterday,1000\n", "2026-03-01T12:00:00Z,many\n"} {
+		if err := ioutil.WriteFile(bad, []byte(text), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := ReadProgress(bad); err == nil {
+			t.Errorf("%q read without error", text)
+		}
+	}
+}
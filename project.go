@@ -0,0 +1,153 @@
+package booktools
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+// ProjectFile is the name of the project file describing a manuscript,
+// found in its directory or one above it.
+const ProjectFile = "booktools.yaml"
+
+// Project is the contents of a project file. Any of it may be left out.
+type Project struct {
+	// Files are the manuscript's files, relative to the project file,
+	// in the order they are read.
+	Files []string `json:"files,omitempty" yaml:"files,omitempty"`
+	// Format is the output format used where none is given.
+	Format string `json:"format,omitempty" yaml:"format,omitempty"`
+	// ChapterRegex matches the sentences which start chapters, in
+	// place of the built-in rules.
+	ChapterRegex string            `json:"chapterRegex,omitempty" yaml:"chapterRegex,omitempty"`
+	Characters   CharacterSettings `json:"characters,omitempty" yaml:"characters,omitempty"`
+	// StopWords are words to ignore, besides StopWords, when looking
+	// for repeated and overused words.
+	StopWords []string       `json:"stopWords,omitempty" yaml:"stopWords,omitempty"`
+	Lint      LintSettings   `json:"lint,omitempty" yaml:"lint,omitempty"`
+	Targets   Targets        `json:"targets,omitempty" yaml:"targets,omitempty"`
+	Server    ServerSettings `json:"server,omitempty" yaml:"server,omitempty"`
+
+	path string
+}
+
+// CharacterSettings correct the characters found in a manuscript:
+// Aliases maps the other names a character goes by to the one they are
+// counted under, and Ignore lists capitalized words which are not
+// characters.
+type CharacterSettings struct {
+	Aliases map[string]string `json:"aliases,omitempty" yaml:"aliases,omitempty"`
+	Ignore  []string          `json:"ignore,omitempty" yaml:"ignore,omitempty"`
+}
+
+// LintSettings choose the lint rules, as the flags of the lint command
+// of the same names do. Rules files are relative to the project file.
+type LintSettings struct {
+	Rules   []string `json:"rules,omitempty" yaml:"rules,omitempty"`
+	Packs   []string `json:"packs,omitempty" yaml:"packs,omitempty"`
+	Enable  []string `json:"enable,omitempty" yaml:"enable,omitempty"`
+	Disable []string `json:"disable,omitempty" yaml:"disable,omitempty"`
+}
+
+// Targets are the word counts a manuscript is meant to reach: Words for
+// the whole book by Deadline, a date such as 2027-03-01, and for each
+// chapter the count in Chapters, or otherwise ChapterWords.
+type Targets struct {
+	Words        int         `json:"words,omitempty" yaml:"words,omitempty"`
+	Deadline     string      `json:"deadline,omitempty" yaml:"deadline,omitempty"`
+	ChapterWords int         `json:"chapterWords,omitempty" yaml:"chapterWords,omitempty"`
+	Chapters     map[int]int `json:"chapters,omitempty" yaml:"chapters,omitempty"`
+}
+
+// ServerSettings are the defaults for the serve command. Theme is
+// relative to the project file.
+type ServerSettings struct {
+	Port  int    `json:"port,omitempty" yaml:"port,omitempty"`
+	Theme string `json:"theme,omitempty" yaml:"theme,omitempty"`
+	Watch bool   `json:"watch,omitempty" yaml:"watch,omitempty"`
+}
+
+// LoadProject reads a project file. Keys which are not understood are
+// errors, so that a misspelt setting is not silently ignored.
+func LoadProject(path string) (*Project, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	p := &Project{path: path}
+	err = yaml.UnmarshalStrict(b, p)
+	if err != nil {
+		return nil, fmt.Errorf("%v: %v", path, err)
+	}
+	if _, err := p.Targets.deadline(); err != nil {
+		return nil, fmt.Errorf("%v: %v", path, err)
+	}
+	return p, nil
+}
+
+// FindProject looks for a project file in dir and each directory above
+// it, returning its path, or "" if there is none.
+func FindProject(dir string) string {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return ""
+	}
+	for {
+		path := filepath.Join(dir, ProjectFile)
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return path
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// Path is the file the project was read from, or "" where there is
+// none.
+func (p *Project) Path() string { return p.path }
+
+// Dir is the directory of the project file.
+func (p *Project) Dir() string {
+	if p.path == "" {
+		return ""
+	}
+	return filepath.Dir(p.path)
+}
+
+// Resolve returns the path of a file named relative to the project
+// file.
+func (p *Project) Resolve(name string) string {
+	if p.path == "" || filepath.IsAbs(name) {
+		return name
+	}
+	return filepath.Join(p.Dir(), name)
+}
+
+// Paths returns the paths of the manuscript's files.
+func (p *Project) Paths() []string {
+	paths := make([]string, len(p.Files))
+	for i, f := range p.Files {
+		paths[i] = p.Resolve(f)
+	}
+	return paths
+}
+
+// Apply makes the project's character and stop word settings those used
+// by every analysis.
+func (p *Project) Apply() {
+	for alias, name := range p.Characters.Aliases {
+		CharacterAliases[alias] = name
+	}
+	for _, w := range p.Characters.Ignore {
+		NotCharacters[w] = true
+	}
+	for _, w := range p.StopWords {
+		StopWords[WordKey(w)] = true
+	}
+}
@@ -0,0 +1,111 @@
+package booktools
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLoadProject(t *testing.T) {
+	tests := []struct {
+		name string
+		yaml string
+		err  string
+	}{
+		{"empty", "", ""},
+		{
+			"every setting",
+			"files: [one.txt, two.txt]\nformat: json\nchapterRegex: '^CHAPTER'\n" +
+				"characters:\n  aliases: {Liz: Elizabeth}\n  ignore: [Monday]\n" +
+				"stopWords: [quite]\nlint:\n  packs: [typography]\n  disable: [TYPO001]\n" +
+				"targets:\n  words: 90000\n  deadline: 2027-03-01\n  chapterWords: 3000\n  chapters: {1: 4500}\n" +
+				"server:\n  port: 8080\n  watch: true\n",
+			"",
+		},
+		{"misspelt key", "fles: [one.txt]\n", "fles"},
+		{"misspelt nested key", "targets:\n  word: 90000\n", "word"},
+		{"bad deadline", "targets:\n  deadline: March\n", "is not a date"},
+		{"wrong type", "files: one.txt\n", "unmarshal"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), ProjectFile)
+			if err := ioutil.WriteFile(path, []byte(test.yaml), 0644); err != nil {
+				t.Fatal(err)
+			}
+			p, err := LoadProject(path)
+			switch {
+			case test.err == "" && err != nil:
+				t.Errorf("error: %v", err)
+			case test.err != "" && err == nil:
+				t.Errorf("no error, want one containing %q", test.err)
+			case test.err != "" && !strings.Contains(err.Error(), test.err):
+				t.Errorf("error %q, want one containing %q", err, test.err)
+			case err == nil && p.Path() != path:
+				t.Errorf("path %q, want %q", p.Path(), path)
+			}
+		})
+	}
+	if _, err := LoadProject(filepath.Join(t.TempDir(), ProjectFile)); err == nil {
+		t.Errorf("a missing project file loaded")
+	}
+}
+
+func TestProjectPaths(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, ProjectFile)
+	if err := ioutil.WriteFile(path, []byte("files: [one.txt, parts/two.txt, /abs/three.txt]\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	p, err := LoadProject(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{filepath.Join(dir, "one.txt"), filepath.Join(dir, "parts", "two.txt"), "/abs/three.txt"}
+	if got := p.Paths(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Paths = %v, want %v", got, want)
+	}
+	if p.Dir() != dir {
+		t.Errorf("Dir = %v, want %v", p.Dir(), dir)
+	}
+	empty := &Project{}
+	if empty.Dir() != "" || empty.Resolve("one.txt") != "one.txt" {
+		t.Errorf("a project without a file resolves one.txt in %q as %q", empty.Dir(), empty.Resolve("one.txt"))
+	}
+}
+
+func TestFindProject(t *testing.T) {
+	root := t.TempDir()
+	book := filepath.Join(root, "book")
+	deep := filepath.Join(book, "drafts", "old")
+	if err := os.MkdirAll(deep, 0755); err != nil {
+		t.Fatal(err)
+	}
+	project := filepath.Join(book, ProjectFile)
+	if err := ioutil.WriteFile(project, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	// A directory of the same name is not a project file.
+	if err := os.Mkdir(filepath.Join(book, "drafts", ProjectFile), 0755); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		dir  string
+		want string
+	}{
+		{book, project},
+		{deep, project},
+		{filepath.Join(book, "drafts"), project},
+		{root, ""},
+	}
+	for _, test := range tests {
+		// Above root, a project file may be found which the test did
+		// not write.
+		if got := FindProject(test.dir); got != test.want && !(test.want == "" && !strings.HasPrefix(got, root)) {
+			t.Errorf("FindProject(%v) = %q, want %q", test.dir, got, test.want)
+		}
+	}
+}
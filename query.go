@@ -0,0 +1,458 @@
+package booktools
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Query is a boolean and proximity search, such as
+//
+//	Anna AND knife
+//	(Anna OR Idris) AND NOT "the door"
+//	Anna NEAR/5 knife
+//	Anna SAME sentence knife
+//
+// Terms are words or quoted phrases, matched as by Search. Terms side by
+// side are joined by AND. NEAR/n matches terms with at most n words
+// between them, and SAME unit matches terms in the same sentence,
+// paragraph or section. Operators must be written in capitals.
+//
+// A Query is evaluated separately in every chunk of its Scope, which is
+// a paragraph unless the query asks for something larger with SAME.
+type Query struct {
+	Scope int
+
+	root queryNode
+	// simple is set when the query is a single word or phrase.
+	simple bool
+}
+
+// ParseQuery parses expr, matching its terms according to opts.
+func ParseQuery(expr string, opts SearchOptions) (*Query, error) {
+	tokens, err := tokenizeQuery(expr)
+	if err != nil {
+		return nil, err
+	}
+	p := &queryParser{tokens: tokens, opts: opts, scope: Paragraph}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("unexpected %v in query", p.tokens[p.pos].text)
+	}
+	_, simple := root.(*termNode)
+	return &Query{Scope: p.scope, root: root, simple: simple}, nil
+}
+
+// IsSimple reports whether the query is a single word or phrase.
+func (q *Query) IsSimple() bool {
+	return q.simple
+}
+
+// Count returns the number of chunks of the query's scope beneath c
+// which match.
+func (q *Query) Count(c *Chunk) int {
+	n := 0
+	for _, s := range c.Find(q.Scope) {
+		if ok, _ := q.root.eval(flattenScope(s)); ok {
+			n = n + 1
+		}
+	}
+	return n
+}
+
+// Find lists the chunks of the query's scope beneath the work root which
+// match, with the words that satisfied the query marked.
+func (q *Query) Find(root *Chunk) QueryResults {
+	results := make(QueryResults, 0)
+	root.Walk(q.Scope, func(a Address, c *Chunk) bool {
+		sw := flattenScope(c)
+		if ok, spans := q.root.eval(sw); ok {
+			results = append(results, QueryHit{Address: a, Segments: segment(sw.words, spans)})
+		}
+		return true
+	})
+	return results
+}
+
+// QuerySegment is a run of text which did or did not satisfy a query.
+type QuerySegment struct {
+	Text  string `json:"text" yaml:"text"`
+	Match bool   `json:"match,omitempty" yaml:"match,omitempty"`
+}
+
+// QueryHit is a chunk which matched a Query.
+type QueryHit struct {
+	Address  Address        `json:"address" yaml:"address"`
+	Segments []QuerySegment `json:"segments" yaml:"segments"`
+}
+
+// Text returns the hit's text with the matching words in brackets.
+func (h QueryHit) Text() string {
+	sb := strings.Builder{}
+	for i, s := range h.Segments {
+		if i > 0 {
+			sb.WriteString(" ")
+		}
+		if s.Match {
+			sb.WriteString("[" + s.Text + "]")
+		} else {
+			sb.WriteString(s.Text)
+		}
+	}
+	return sb.String()
+}
+
+// QueryResults lists the chunks which matched a Query.
+type QueryResults []QueryHit
+
+func (l QueryResults) Columns() []string { return []string{"Address", "Text"} }
+
+func (l QueryResults) Rows() [][]string {
+	rows := make([][]string, len(l))
+	for i, h := range l {
+		rows[i] = []string{h.Address.String(), h.Text()}
+	}
+	return rows
+}
+
+func (l QueryResults) String() string {
+	sb := strings.Builder{}
+	for _, h := range l {
+		sb.WriteString(fmt.Sprintf("%-10v %v\n", h.Address, h.Text()))
+	}
+	return sb.String()
+}
+
+// segment splits words into runs inside and outside of spans.
+func segment(words []*Chunk, spans []span) []QuerySegment {
+	marked := make([]bool, len(words))
+	for _, s := range spans {
+		for i := s.start; i < s.end; i++ {
+			marked[i] = true
+		}
+	}
+	segments := make([]QuerySegment, 0)
+	for i := 0; i < len(words); {
+		j := i
+		for j < len(words) && marked[j] == marked[i] {
+			j = j + 1
+		}
+		segments = append(segments, QuerySegment{Text: joinWords(words[i:j]), Match: marked[i]})
+		i = j
+	}
+	return segments
+}
+
+// scopeWords is the words of one scope chunk, with the index of the
+// sentence, paragraph and section holding each word.
+type scopeWords struct {
+	words []*Chunk
+	index map[int][]int
+}
+
+func flattenScope(c *Chunk) *scopeWords {
+	sw := &scopeWords{words: make([]*Chunk, 0), index: make(map[int][]int)}
+	counts := make(map[int]int)
+	var flatten func(c *Chunk)
+	flatten = func(c *Chunk) {
+		if c.Unit == Word {
+			sw.words = append(sw.words, c)
+			for _, unit := range []int{Sentence, Paragraph, Section} {
+				sw.index[unit] = append(sw.index[unit], counts[unit])
+			}
+			return
+		}
+		counts[c.Unit] = counts[c.Unit] + 1
+		for _, child := range c.Children {
+			flatten(child)
+		}
+	}
+	flatten(c)
+	return sw
+}
+
+// queryNode is a node in a parsed Query. eval reports whether the node
+// matches in the scope, and which words it matched.
+type queryNode interface {
+	eval(sw *scopeWords) (bool, []span)
+}
+
+type termNode struct {
+	m *wordMatcher
+}
+
+func (n *termNode) eval(sw *scopeWords) (bool, []span) {
+	spans := n.m.match(sw.words)
+	return len(spans) > 0, spans
+}
+
+type andNode struct {
+	a, b queryNode
+}
+
+func (n *andNode) eval(sw *scopeWords) (bool, []span) {
+	okA, spansA := n.a.eval(sw)
+	if !okA {
+		return false, nil
+	}
+	okB, spansB := n.b.eval(sw)
+	if !okB {
+		return false, nil
+	}
+	return true, append(spansA, spansB...)
+}
+
+type orNode struct {
+	a, b queryNode
+}
+
+func (n *orNode) eval(sw *scopeWords) (bool, []span) {
+	okA, spansA := n.a.eval(sw)
+	okB, spansB := n.b.eval(sw)
+	return okA || okB, append(spansA, spansB...)
+}
+
+type notNode struct {
+	a queryNode
+}
+
+func (n *notNode) eval(sw *scopeWords) (bool, []span) {
+	ok, _ := n.a.eval(sw)
+	return !ok, nil
+}
+
+// pairNode matches spans of a and b which are near enough to each
+// other: within distance words for NEAR, or in the same unit for SAME.
+type pairNode struct {
+	a, b     queryNode
+	distance int
+	unit     int
+}
+
+func (n *pairNode) eval(sw *scopeWords) (bool, []span) {
+	_, spansA := n.a.eval(sw)
+	_, spansB := n.b.eval(sw)
+	spans := make([]span, 0)
+	for _, sa := range spansA {
+		for _, sb := range spansB {
+			if n.close(sw, sa, sb) {
+				spans = append(spans, sa, sb)
+			}
+		}
+	}
+	return len(spans) > 0, spans
+}
+
+func (n *pairNode) close(sw *scopeWords, sa span, sb span) bool {
+	if n.unit > 0 {
+		return sw.index[n.unit][sa.start] == sw.index[n.unit][sb.start]
+	}
+	gap := 0
+	if sa.end <= sb.start {
+		gap = sb.start - sa.end
+	} else if sb.end <= sa.start {
+		gap = sa.start - sb.end
+	}
+	return gap <= n.distance
+}
+
+const (
+	tokenTerm = iota
+	tokenPhrase
+	tokenOpen
+	tokenClose
+)
+
+type queryToken struct {
+	kind int
+	text string
+}
+
+func tokenizeQuery(expr string) ([]queryToken, error) {
+	tokens := make([]queryToken, 0)
+	runes := []rune(expr)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i = i + 1
+		case r == '(':
+			tokens = append(tokens, queryToken{tokenOpen, "("})
+			i = i + 1
+		case r == ')':
+			tokens = append(tokens, queryToken{tokenClose, ")"})
+			i = i + 1
+		case r == '"':
+			j := i + 1
+			for j < len(runes) && runes[j] != '"' {
+				j = j + 1
+			}
+			if j == len(runes) {
+				return nil, fmt.Errorf("unterminated phrase in query")
+			}
+			tokens = append(tokens, queryToken{tokenPhrase, string(runes[i+1 : j])})
+			i = j + 1
+		default:
+			j := i
+			for j < len(runes) && !unicode.IsSpace(runes[j]) && runes[j] != '(' && runes[j] != ')' {
+				j = j + 1
+			}
+			tokens = append(tokens, queryToken{tokenTerm, string(runes[i:j])})
+			i = j
+		}
+	}
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("empty query")
+	}
+	return tokens, nil
+}
+
+type queryParser struct {
+	tokens []queryToken
+	pos    int
+	opts   SearchOptions
+	scope  int
+}
+
+func (p *queryParser) peek() (queryToken, bool) {
+	if p.pos >= len(p.tokens) {
+		return queryToken{}, false
+	}
+	return p.tokens[p.pos], true
+}
+
+// isOperator reports whether the next token is the bare word op.
+func (p *queryParser) isOperator(op string) bool {
+	t, ok := p.peek()
+	return ok && t.kind == tokenTerm && (t.text == op || (op == "NEAR" && strings.HasPrefix(t.text, "NEAR/")))
+}
+
+func (p *queryParser) parseOr() (queryNode, error) {
+	n, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.isOperator("OR") {
+		p.pos = p.pos + 1
+		b, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		n = &orNode{n, b}
+	}
+	return n, nil
+}
+
+func (p *queryParser) parseAnd() (queryNode, error) {
+	n, err := p.parsePair()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		t, ok := p.peek()
+		if !ok || t.kind == tokenClose || p.isOperator("OR") {
+			return n, nil
+		}
+		if p.isOperator("AND") {
+			p.pos = p.pos + 1
+		}
+		b, err := p.parsePair()
+		if err != nil {
+			return nil, err
+		}
+		n = &andNode{n, b}
+	}
+}
+
+func (p *queryParser) parsePair() (queryNode, error) {
+	n, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for p.isOperator("NEAR") || p.isOperator("SAME") {
+		pair := &pairNode{a: n}
+		t, _ := p.peek()
+		p.pos = p.pos + 1
+		if t.text == "SAME" {
+			u, ok := p.peek()
+			pair.unit = StringToUnit(u.text)
+			if !ok || pair.unit < Sentence || pair.unit > Section {
+				return nil, fmt.Errorf("SAME must be followed by sentence, paragraph or section")
+			}
+			if pair.unit > p.scope {
+				p.scope = pair.unit
+			}
+			p.pos = p.pos + 1
+		} else {
+			pair.distance, err = strconv.Atoi(strings.TrimPrefix(t.text, "NEAR/"))
+			if err != nil || pair.distance < 0 {
+				return nil, fmt.Errorf("could not parse %v, expected NEAR/n", t.text)
+			}
+			// "NEAR/5 words" reads naturally, so allow it
+			if u, ok := p.peek(); ok && u.kind == tokenTerm && strings.EqualFold(u.text, "words") {
+				p.pos = p.pos + 1
+			}
+		}
+		pair.b, err = p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := pair.a.(*notNode); ok {
+			return nil, fmt.Errorf("NOT cannot be used with %v", t.text)
+		}
+		if _, ok := pair.b.(*notNode); ok {
+			return nil, fmt.Errorf("NOT cannot be used with %v", t.text)
+		}
+		n = pair
+	}
+	return n, nil
+}
+
+func (p *queryParser) parseNot() (queryNode, error) {
+	if p.isOperator("NOT") {
+		p.pos = p.pos + 1
+		n, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return &notNode{n}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *queryParser) parsePrimary() (queryNode, error) {
+	t, ok := p.peek()
+	if !ok {
+		return nil, fmt.Errorf("query ends unexpectedly")
+	}
+	p.pos = p.pos + 1
+	switch t.kind {
+	case tokenOpen:
+		n, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if c, ok := p.peek(); !ok || c.kind != tokenClose {
+			return nil, fmt.Errorf("missing ) in query")
+		}
+		p.pos = p.pos + 1
+		return n, nil
+	case tokenClose:
+		return nil, fmt.Errorf("unexpected ) in query")
+	}
+	switch t.text {
+	case "AND", "OR", "SAME":
+		return nil, fmt.Errorf("unexpected %v in query", t.text)
+	}
+	opts := p.opts
+	opts.Regexp = false
+	m, err := newWordMatcher(t.text, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &termNode{m}, nil
+}
@@ -0,0 +1,128 @@
+package booktools
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseQuery(t *testing.T) {
+	tests := []struct {
+		expr   string
+		scope  int
+		simple bool
+		err    string
+	}{
+		{"knife", Paragraph, true, ""},
+		{`"the knife"`, Paragraph, true, ""},
+		{"Anna knife", Paragraph, false, ""},
+		{"Anna AND NOT knife", Paragraph, false, ""},
+		{"(Anna OR Idris) AND knife", Paragraph, false, ""},
+		{"Anna NEAR/3 knife", Paragraph, false, ""},
+		{"Anna NEAR/3 words knife", Paragraph, false, ""},
+		{"Anna SAME sentence knife", Paragraph, false, ""},
+		{"Anna SAME section knife", Section, false, ""},
+		{"", 0, false, "empty query"},
+		{`"the knife`, 0, false, "unterminated phrase"},
+		{"(Anna OR Idris", 0, false, "missing )"},
+		{"Anna OR Idris)", 0, false, "unexpected )"},
+		{"Anna AND", 0, false, "query ends unexpectedly"},
+		{"OR Anna", 0, false, "unexpected OR"},
+		{"Anna NEAR/x knife", 0, false, "expected NEAR/n"},
+		{"Anna NEAR/-1 knife", 0, false, "expected NEAR/n"},
+		{"Anna SAME chapter knife", 0, false, "SAME must be followed by"},
+		{"Anna SAME", 0, false, "SAME must be followed by"},
+		{"Anna NEAR/3 NOT knife", 0, false, "NOT cannot be used with NEAR/3"},
+		{"NOT Anna SAME sentence knife", 0, false, "NOT cannot be used with SAME"},
+		{`""`, 0, false, "nothing to search for"},
+	}
+	for _, test := range tests {
+		t.Run(test.expr, func(t *testing.T) {
+			q, err := ParseQuery(test.expr, SearchOptions{})
+			switch {
+			case test.err == "" && err != nil:
+				t.Errorf("error: %v", err)
+			case test.err != "" && err == nil:
+				t.Errorf("no error, want one containing %q", test.err)
+			case test.err != "" && !strings.Contains(err.Error(), test.err):
+				t.Errorf("error %q, want one containing %q", err, test.err)
+			case err == nil && (q.Scope != test.scope || q.IsSimple() != test.simple):
+				t.Errorf("scope %v, simple %v, want %v, %v", q.Scope, q.IsSimple(), test.scope, test.simple)
+			}
+		})
+	}
+}
+
+func TestQueryFind(t *testing.T) {
+	text := "Chapter 1\n\n" +
+		"Anna held the knife. Idris watched her.\n\n" +
+		"Idris took the knife from the table. Then he left by the door.\n\n" +
+		"Anna slept.\n\n" +
+		"---\n\n" +
+		"Anna woke.\n\n" +
+		"The knife was gone.\n"
+	tests := []struct {
+		expr string
+		hits []string
+	}{
+		{"knife", []string{
+			"1.1.2 Anna held the [knife.] Idris watched her.",
+			"1.1.3 Idris took the [knife] from the table. Then he left by the door.",
+			"1.2.2 The [knife] was gone.",
+		}},
+		{"Anna knife", []string{
+			"1.1.2 [Anna] held the [knife.] Idris watched her.",
+		}},
+		{"knife AND NOT Anna", []string{
+			"1.1.3 Idris took the [knife] from the table. Then he left by the door.",
+			"1.2.2 The [knife] was gone.",
+		}},
+		{"(Anna OR Idris) AND knife", []string{
+			"1.1.2 [Anna] held the [knife. Idris] watched her.",
+			"1.1.3 [Idris] took the [knife] from the table. Then he left by the door.",
+		}},
+		{`"the knife" OR "the door"`, []string{
+			"1.1.2 Anna held [the knife.] Idris watched her.",
+			"1.1.3 Idris took [the knife] from the table. Then he left by [the door.]",
+			"1.2.2 [The knife] was gone.",
+		}},
+		{"Idris NEAR/2 knife", []string{
+			"1.1.2 Anna held the [knife. Idris] watched her.",
+			"1.1.3 [Idris] took the [knife] from the table. Then he left by the door.",
+		}},
+		{"Idris NEAR/4 table", []string{}},
+		{"Idris NEAR/5 table", []string{
+			"1.1.3 [Idris] took the knife from the [table.] Then he left by the door.",
+		}},
+		{"knife SAME sentence Idris", []string{
+			"1.1.3 [Idris] took the [knife] from the table. Then he left by the door.",
+		}},
+		{"knife SAME section Anna", []string{
+			"1.1 Chapter 1 [Anna] held the [knife.] Idris watched her. Idris took the [knife] from the table. Then he left by the door. [Anna] slept.",
+			"1.2 [Anna] woke. The [knife] was gone.",
+		}},
+		{"knife SAME paragraph door", []string{
+			"1.1.3 Idris took the [knife] from the table. Then he left by the [door.]",
+		}},
+	}
+	root := Parse(text, nil)
+	for _, test := range tests {
+		t.Run(test.expr, func(t *testing.T) {
+			q, err := ParseQuery(test.expr, SearchOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			l := q.Find(root)
+			hits := make([]string, len(l))
+			for i, h := range l {
+				hits[i] = h.Address.String() + " " + h.Text()
+			}
+			if !reflect.DeepEqual(hits, test.hits) {
+				t.Errorf("hits %q, want %q", hits, test.hits)
+			}
+			if n := q.Count(root); n != len(test.hits) {
+				t.Errorf("Count = %d, want %d", n, len(test.hits))
+			}
+		})
+	}
+}
@@ -0,0 +1,184 @@
+package booktools
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+func init() {
+	RegisterAnalyzer(ReadabilityAnalyzer{IncludeSections: true})
+}
+
+// CountSyllables estimates the number of syllables in an English word by
+// counting groups of vowels, less a silent final e or ed.
+func CountSyllables(w string) int {
+	w = strings.ToLower(TrimPunctuation(w))
+	letters := make([]rune, 0, len(w))
+	for _, r := range w {
+		if unicode.IsLetter(r) {
+			letters = append(letters, r)
+		}
+	}
+	if len(letters) == 0 {
+		return 0
+	}
+	if len(letters) <= 3 {
+		return 1
+	}
+	isVowel := func(r rune) bool {
+		return strings.ContainsRune("aeiouy", r)
+	}
+	n := 0
+	prev := false
+	for _, r := range letters {
+		v := isVowel(r)
+		if v && !prev {
+			n = n + 1
+		}
+		prev = v
+	}
+	last := len(letters) - 1
+	switch {
+	case letters[last] == 'e' && !(letters[last-1] == 'l' && !isVowel(letters[last-2])) && !isVowel(letters[last-1]):
+		// silent e, as in "make", but not "table"
+		n = n - 1
+	case letters[last] == 'd' && letters[last-1] == 'e' && letters[last-2] != 't' && letters[last-2] != 'd' && !isVowel(letters[last-2]):
+		// silent ed, as in "walked", but not "wanted"
+		n = n - 1
+	}
+	if n < 1 {
+		n = 1
+	}
+	return n
+}
+
+// Readability holds the counts behind, and the scores of, the common
+// readability formulas for a passage.
+type Readability struct {
+	Words         int `json:"words" yaml:"words"`
+	Sentences     int `json:"sentences" yaml:"sentences"`
+	Syllables     int `json:"syllables" yaml:"syllables"`
+	Letters       int `json:"letters" yaml:"letters"`
+	Polysyllables int `json:"polysyllables" yaml:"polysyllables"`
+
+	FleschReadingEase  float64 `json:"fleschReadingEase" yaml:"fleschReadingEase"`
+	FleschKincaidGrade float64 `json:"fleschKincaidGrade" yaml:"fleschKincaidGrade"`
+	GunningFog         float64 `json:"gunningFog" yaml:"gunningFog"`
+	SMOG               float64 `json:"smog" yaml:"smog"`
+	ColemanLiau        float64 `json:"colemanLiau" yaml:"colemanLiau"`
+	ARI                float64 `json:"ari" yaml:"ari"`
+}
+
+// Readability counts the words, sentences, syllables and letters
+// beneath c and scores them.
+func (c *Chunk) Readability() Readability {
+	r := Readability{}
+	iter := NewChunkIterator(c)
+	for iter.NextChunk() != nil {
+		switch iter.Value().Unit {
+		case Sentence:
+			r.Sentences = r.Sentences + 1
+		case Word:
+			w := TrimPunctuation(iter.Value().Word)
+			if w == "" {
+				continue
+			}
+			r.Words = r.Words + 1
+			s := CountSyllables(w)
+			r.Syllables = r.Syllables + s
+			if s >= 3 {
+				r.Polysyllables = r.Polysyllables + 1
+			}
+			for _, l := range w {
+				if unicode.IsLetter(l) || unicode.IsDigit(l) {
+					r.Letters = r.Letters + 1
+				}
+			}
+		}
+	}
+	r.score()
+	return r
+}
+
+func (r *Readability) score() {
+	if r.Words == 0 || r.Sentences == 0 {
+		return
+	}
+	words := float64(r.Words)
+	sentences := float64(r.Sentences)
+	wordsPerSentence := words / sentences
+	syllablesPerWord := float64(r.Syllables) / words
+
+	r.FleschReadingEase = 206.835 - 1.015*wordsPerSentence - 84.6*syllablesPerWord
+	r.FleschKincaidGrade = 0.39*wordsPerSentence + 11.8*syllablesPerWord - 15.59
+	r.GunningFog = 0.4 * (wordsPerSentence + 100*float64(r.Polysyllables)/words)
+	r.SMOG = 1.0430*math.Sqrt(float64(r.Polysyllables)*30/sentences) + 3.1291
+	r.ColemanLiau = 0.0588*(100*float64(r.Letters)/words) - 0.296*(100*sentences/words) - 15.8
+	r.ARI = 4.71*float64(r.Letters)/words + 0.5*wordsPerSentence - 21.43
+}
+
+// ReadabilityRow is the readability of one chapter, section or work.
+type ReadabilityRow struct {
+	Address     string `json:"address" yaml:"address"`
+	Unit        string `json:"unit" yaml:"unit"`
+	Readability `yaml:",inline"`
+}
+
+// ReadabilityReport lists the readability of the work, then of each
+// chapter followed by its sections.
+type ReadabilityReport []ReadabilityRow
+
+func (l ReadabilityReport) Columns() []string {
+	return []string{"Address", "Unit", "Words", "Sentences", "FleschReadingEase", "FleschKincaidGrade", "GunningFog", "SMOG", "ColemanLiau", "ARI"}
+}
+
+func (l ReadabilityReport) Rows() [][]string {
+	f := func(v float64) string { return strconv.FormatFloat(v, 'f', 1, 64) }
+	rows := make([][]string, len(l))
+	for i, r := range l {
+		rows[i] = []string{r.Address, r.Unit, strconv.Itoa(r.Words), strconv.Itoa(r.Sentences),
+			f(r.FleschReadingEase), f(r.FleschKincaidGrade), f(r.GunningFog), f(r.SMOG), f(r.ColemanLiau), f(r.ARI)}
+	}
+	return rows
+}
+
+func (l ReadabilityReport) String() string {
+	sb := strings.Builder{}
+	sb.WriteString(fmt.Sprintf("%-8v %-8v %7v %6v %7v %6v %6v %6v %6v %6v\n", "Address", "Unit", "Words", "Sent", "Ease", "Grade", "Fog", "SMOG", "CLI", "ARI"))
+	for _, r := range l {
+		sb.WriteString(fmt.Sprintf("%-8v %-8v %7d %6d %7.1f %6.1f %6.1f %6.1f %6.1f %6.1f\n", r.Address, r.Unit, r.Words, r.Sentences,
+			r.FleschReadingEase, r.FleschKincaidGrade, r.GunningFog, r.SMOG, r.ColemanLiau, r.ARI))
+	}
+	return sb.String()
+}
+
+// ReadabilityAnalyzer reports the readability of the work and each of
+// its chapters, and optionally of each section.
+type ReadabilityAnalyzer struct {
+	IncludeSections bool
+}
+
+func (a ReadabilityAnalyzer) Name() string { return "readability" }
+func (a ReadabilityAnalyzer) Description() string {
+	return "Scores the readability of the work, each chapter and each section"
+}
+func (a ReadabilityAnalyzer) Unit() int { return Section }
+
+func (a ReadabilityAnalyzer) Analyze(c *Chunk) Result {
+	l := ReadabilityReport{ReadabilityRow{Address: "", Unit: UnitToString(Work), Readability: c.Readability()}}
+	for i, chapter := range c.Chapters() {
+		ca := Address{Chapter: i + 1}
+		l = append(l, ReadabilityRow{Address: ca.String(), Unit: UnitToString(Chapter), Readability: chapter.Readability()})
+		if !a.IncludeSections {
+			continue
+		}
+		for j, section := range chapter.Children {
+			sa := Address{Chapter: i + 1, Section: j + 1}
+			l = append(l, ReadabilityRow{Address: sa.String(), Unit: UnitToString(Section), Readability: section.Readability()})
+		}
+	}
+	return l
+}
@@ -0,0 +1,401 @@
+package booktools
+
+import (
+	"embed"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+//go:embed rules
+var rulePacks embed.FS
+
+// Scopes a rule's pattern may match within. A match never spans two
+// of its scope.
+const (
+	ScopeLine      = "line"
+	ScopeSentence  = "sentence"
+	ScopeParagraph = "paragraph"
+)
+
+// Rule is a check declared in a rules file. Pattern is a regular
+// expression, while Tokens is a list of words separated by spaces,
+// matched without regard to case, where * matches any word and a|b
+// matches either. The Message and Suggestion may refer to the match as
+// $0 and to the groups of a Pattern as $1, $2 and so on.
+type Rule struct {
+	ID         string `json:"id" yaml:"id"`
+	Pattern    string `json:"pattern,omitempty" yaml:"pattern,omitempty"`
+	Tokens     string `json:"tokens,omitempty" yaml:"tokens,omitempty"`
+	Scope      string `json:"scope,omitempty" yaml:"scope,omitempty"`
+	Severity   string `json:"severity,omitempty" yaml:"severity,omitempty"`
+	Message    string `json:"message,omitempty" yaml:"message,omitempty"`
+	Suggestion string `json:"suggestion,omitempty" yaml:"suggestion,omitempty"`
+	// Disabled rules are only checked when named in a RuleSet's Enable.
+	Disabled bool `json:"disabled,omitempty" yaml:"disabled,omitempty"`
+
+	re *regexp.Regexp
+}
+
+// RuleSet is the rules to lint a manuscript with, along with the IDs of
+// rules to switch on or off. The TypographyRules are always part of a
+// RuleSet, and can be switched off like any other.
+type RuleSet struct {
+	Rules   []Rule   `json:"rules" yaml:"rules"`
+	Enable  []string `json:"enable,omitempty" yaml:"enable,omitempty"`
+	Disable []string `json:"disable,omitempty" yaml:"disable,omitempty"`
+}
+
+// LoadRules reads a RuleSet from YAML.
+func LoadRules(r io.Reader) (*RuleSet, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	s := &RuleSet{}
+	err = yaml.UnmarshalStrict(data, s)
+	if err != nil {
+		return nil, err
+	}
+	for i := range s.Rules {
+		err = s.Rules[i].compile()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// RulePacks lists the names of the rule packs built into booktools.
+func RulePacks() []string {
+	names := make([]string, 0)
+	entries, _ := rulePacks.ReadDir("rules")
+	for _, e := range entries {
+		names = append(names, strings.TrimSuffix(e.Name(), ".yaml"))
+	}
+	sort.Strings(names)
+	return names
+}
+
+// RulePack loads the named rule pack built into booktools.
+func RulePack(name string) (*RuleSet, error) {
+	f, err := rulePacks.Open(path.Join("rules", name+".yaml"))
+	if err != nil {
+		return nil, fmt.Errorf("unknown rule pack %q, expected one of %v", name, strings.Join(RulePacks(), ", "))
+	}
+	defer f.Close()
+	s, err := LoadRules(f)
+	if err != nil {
+		return nil, fmt.Errorf("rule pack %v: %v", name, err)
+	}
+	return s, nil
+}
+
+// compile checks the rule and prepares its pattern.
+func (r *Rule) compile() error {
+	if r.ID == "" {
+		return fmt.Errorf("rule without an id")
+	}
+	if (r.Pattern == "") == (r.Tokens == "") {
+		return fmt.Errorf("rule %v: expected one of pattern or tokens", r.ID)
+	}
+	switch r.Scope {
+	case "":
+		r.Scope = ScopeParagraph
+	case ScopeLine, ScopeSentence, ScopeParagraph:
+	default:
+		return fmt.Errorf("rule %v: unknown scope %q", r.ID, r.Scope)
+	}
+	switch r.Severity {
+	case "":
+		r.Severity = SeverityWarning
+	case SeverityError, SeverityWarning, SeverityInfo:
+	default:
+		return fmt.Errorf("rule %v: unknown severity %q", r.ID, r.Severity)
+	}
+	expr := r.Pattern
+	if r.Tokens != "" {
+		expr = tokensExpr(r.Tokens)
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return fmt.Errorf("rule %v: %v", r.ID, err)
+	}
+	r.re = re
+	if r.Message == "" {
+		r.Message = "\"$0\" matches " + r.ID
+	}
+	return nil
+}
+
+// tokensExpr turns a token pattern into a regular expression.
+func tokensExpr(tokens string) string {
+	parts := make([]string, 0)
+	for _, t := range strings.Fields(tokens) {
+		if t == "*" {
+			parts = append(parts, `[\p{L}\p{N}'’-]+`)
+			continue
+		}
+		alternatives := strings.Split(t, "|")
+		for i, a := range alternatives {
+			alternatives[i] = regexp.QuoteMeta(a)
+		}
+		parts = append(parts, "(?:"+strings.Join(alternatives, "|")+")")
+	}
+	return `(?i)\b` + strings.Join(parts, `\s+`) + `\b`
+}
+
+// Add adds the rules of o to s. A rule with the ID of one already in s
+// replaces it.
+func (s *RuleSet) Add(o *RuleSet) {
+	for _, r := range o.Rules {
+		replaced := false
+		for i := range s.Rules {
+			if s.Rules[i].ID == r.ID {
+				s.Rules[i] = r
+				replaced = true
+			}
+		}
+		if !replaced {
+			s.Rules = append(s.Rules, r)
+		}
+	}
+	s.Enable = append(s.Enable, o.Enable...)
+	s.Disable = append(s.Disable, o.Disable...)
+}
+
+// enabled reports whether the rule id, disabled by default or not, is
+// to be checked.
+func (s *RuleSet) enabled(id string, disabled bool) bool {
+	for _, d := range s.Disable {
+		if d == id {
+			return false
+		}
+	}
+	if !disabled {
+		return true
+	}
+	for _, e := range s.Enable {
+		if e == id {
+			return true
+		}
+	}
+	return false
+}
+
+// Lint checks text against the TypographyRules and the rules of s which
+// are enabled, leaving out findings suppressed by comments such as
+// <!-- booktools-disable HOUSE001 -->.
+func (s *RuleSet) Lint(text string) LintFindings {
+	l := newLinter(text)
+	l.typography()
+	for _, r := range s.Rules {
+		if r.re == nil && r.compile() != nil {
+			continue
+		}
+		if s.enabled(r.ID, r.Disabled) {
+			l.match(r)
+		}
+	}
+	suppressed := l.suppressions()
+	findings := make(LintFindings, 0, len(l.findings))
+	for _, f := range l.findings {
+		off := suppressed[f.Line]
+		if !s.enabled(f.Rule, false) || off[f.Rule] || off["*"] {
+			continue
+		}
+		findings = append(findings, f)
+	}
+	sort.SliceStable(findings, func(i, j int) bool {
+		return findings[i].offset < findings[j].offset
+	})
+	return findings
+}
+
+// match reports each match of r within its scope.
+func (l *linter) match(r Rule) {
+	for _, u := range l.units(r.Scope) {
+		unit := l.text[u.start:u.end]
+		for _, m := range r.re.FindAllStringSubmatchIndex(unit, -1) {
+			if m[0] == m[1] {
+				continue
+			}
+			message := string(r.re.ExpandString(nil, r.Message, unit, m))
+			var fix *Edit
+			if r.Suggestion != "" {
+				fix = &Edit{Offset: u.start + m[0], Length: m[1] - m[0], Text: string(r.re.ExpandString(nil, r.Suggestion, unit, m))}
+			}
+			l.report(r.ID, r.Severity, u.start+m[0], message, fix)
+		}
+	}
+}
+
+var sentenceEnd = regexp.MustCompile(`[.!?…]+["”’')]*\s+`)
+
+// units splits the text into lines, sentences or paragraphs.
+func (l *linter) units(scope string) []textRange {
+	switch scope {
+	case ScopeLine:
+		us := make([]textRange, len(l.lineStarts))
+		for i, ls := range l.lineStarts {
+			le := len(l.text)
+			if i+1 < len(l.lineStarts) {
+				le = l.lineStarts[i+1]
+			}
+			us[i] = textRange{ls, le}
+		}
+		return us
+	case ScopeSentence:
+		us := make([]textRange, 0)
+		for _, p := range l.paragraphs() {
+			start := p.start
+			for _, m := range sentenceEnd.FindAllStringIndex(l.text[p.start:p.end], -1) {
+				us = append(us, textRange{start, p.start + m[1]})
+				start = p.start + m[1]
+			}
+			if start < p.end {
+				us = append(us, textRange{start, p.end})
+			}
+		}
+		return us
+	}
+	return l.paragraphs()
+}
+
+var suppression = regexp.MustCompile(`<!--\s*booktools-(disable-next-line|disable-line|disable|enable)\b([^>]*?)\s*-->`)
+var trailingComment = regexp.MustCompile(`<!--[^>]*-->$`)
+
+// suppressions reads the suppression comments in the text, returning
+// for each line the set of rule IDs not to report on it, where "*"
+// stands for every rule.
+//
+//	<!-- booktools-disable TYPO001 HOUSE002 -->  from here on
+//	<!-- booktools-enable TYPO001 -->            from here on
+//	<!-- booktools-disable-line -->              on this line
+//	<!-- booktools-disable-next-line TYPO004 --> on the next line
+func (l *linter) suppressions() map[int]map[string]bool {
+	lines := make(map[int]map[string]bool)
+	if !strings.Contains(l.text, "booktools-") {
+		return lines
+	}
+	current := make(map[string]bool)
+	next := make(map[string]bool)
+	for i, ls := range l.lineStarts {
+		le := len(l.text)
+		if i+1 < len(l.lineStarts) {
+			le = l.lineStarts[i+1]
+		}
+		off := make(map[string]bool)
+		for id := range next {
+			off[id] = true
+		}
+		next = make(map[string]bool)
+		for _, m := range suppression.FindAllStringSubmatch(l.text[ls:le], -1) {
+			ids := strings.FieldsFunc(m[2], func(r rune) bool { return r == ',' || r == ' ' || r == '\t' })
+			if len(ids) == 0 {
+				ids = []string{"*"}
+			}
+			for _, id := range ids {
+				switch m[1] {
+				case "disable":
+					current[id] = true
+				case "enable":
+					if id == "*" {
+						current = make(map[string]bool)
+					}
+					delete(current, id)
+				case "disable-line":
+					off[id] = true
+				case "disable-next-line":
+					next[id] = true
+				}
+			}
+		}
+		for id := range current {
+			off[id] = true
+		}
+		if len(off) > 0 {
+			lines[i+1] = off
+		}
+	}
+	return lines
+}
+
+// severityRank orders the severities, from info up to error.
+func severityRank(severity string) int {
+	switch severity {
+	case SeverityError:
+		return 3
+	case SeverityWarning:
+		return 2
+	case SeverityInfo:
+		return 1
+	}
+	return 0
+}
+
+// Count returns the number of findings of the given severity or worse.
+func (l LintFindings) Count(severity string) int {
+	n := 0
+	for _, f := range l {
+		if severityRank(f.Severity) >= severityRank(severity) {
+			n = n + 1
+		}
+	}
+	return n
+}
+
+// RuleList describes the rules of a RuleSet.
+type RuleList []RuleSummary
+
+// RuleSummary describes a rule and whether it is enabled.
+type RuleSummary struct {
+	ID          string `json:"id" yaml:"id"`
+	Severity    string `json:"severity" yaml:"severity"`
+	Scope       string `json:"scope" yaml:"scope"`
+	Enabled     bool   `json:"enabled" yaml:"enabled"`
+	Description string `json:"description" yaml:"description"`
+}
+
+// List describes the TypographyRules and the rules of s.
+func (s *RuleSet) List() RuleList {
+	l := make(RuleList, 0, len(TypographyRules)+len(s.Rules))
+	for _, r := range TypographyRules {
+		l = append(l, RuleSummary{ID: r.ID, Severity: r.Severity, Scope: "text", Enabled: s.enabled(r.ID, false), Description: r.Description})
+	}
+	for _, r := range s.Rules {
+		l = append(l, RuleSummary{ID: r.ID, Severity: r.Severity, Scope: r.Scope, Enabled: s.enabled(r.ID, r.Disabled), Description: r.Message})
+	}
+	return l
+}
+
+func (l RuleList) Columns() []string {
+	return []string{"ID", "Severity", "Scope", "Enabled", "Description"}
+}
+
+func (l RuleList) Rows() [][]string {
+	rows := make([][]string, len(l))
+	for i, r := range l {
+		rows[i] = []string{r.ID, r.Severity, r.Scope, strconv.FormatBool(r.Enabled), r.Description}
+	}
+	return rows
+}
+
+func (l RuleList) String() string {
+	sb := strings.Builder{}
+	for _, r := range l {
+		state := "on"
+		if !r.Enabled {
+			state = "off"
+		}
+		sb.WriteString(fmt.Sprintf("%-10v %-8v %-9v %-4v %v\n", r.ID, r.Severity, r.Scope, state, r.Description))
+	}
+	return sb.String()
+}
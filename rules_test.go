@@ -0,0 +1,285 @@
+package booktools
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLoadRules(t *testing.T) {
+	tests := []struct {
+		name string
+		yaml string
+		err  string
+	}{
+		{"tokens", "rules:\n  - id: R1\n    tokens: very * indeed\n", ""},
+		{"pattern", "rules:\n  - id: R1\n    pattern: '\\bvery\\b'\n    scope: sentence\n    severity: info\n", ""},
+		{"enable and disable", "rules:\n  - id: R1\n    tokens: very\n    disabled: true\nenable: [R1]\ndisable: [TYPO001]\n", ""},
+		{"no id", "rules:\n  - tokens: very\n", "without an id"},
+		{"neither pattern nor tokens", "rules:\n  - id: R1\n", "expected one of pattern or tokens"},
+		{"both pattern and tokens", "rules:\n  - id: R1\n    tokens: very\n    pattern: very\n", "expected one of pattern or tokens"},
+		{"bad scope", "rules:\n  - id: R1\n    tokens: very\n    scope: chapter\n", "unknown scope"},
+		{"bad severity", "rules:\n  - id: R1\n    tokens: very\n    severity: fatal\n", "unknown severity"},
+		{"bad pattern", "rules:\n  - id: R1\n    pattern: '(very'\n", "rule R1"},
+		{"unknown key", "rules:\n  - id: R1\n    tokens: very\n    level: info\n", "not found"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := LoadRules(strings.NewReader(test.yaml))
+			switch {
+			case test.err == "" && err != nil:
+				t.Errorf("error: %v", err)
+			case test.err != "" && err == nil:
+				t.Errorf("no error, want one containing %q", test.err)
+			case test.err != "" && !strings.Contains(err.Error(), test.err):
+				t.Errorf("error %q, want one containing %q", err, test.err)
+			}
+		})
+	}
+}
+
+func TestRulePacks(t *testing.T) {
+	names := RulePacks()
+	if len(names) == 0 {
+		t.Fatal("no rule packs")
+	}
+	for _, name := range names {
+		s, err := RulePack(name)
+		if err != nil {
+			t.Errorf("%v: %v", name, err)
+			continue
+		}
+		if len(s.Rules) == 0 {
+			t.Errorf("%v has no rules", name)
+		}
+	}
+	if _, err := RulePack("nonesuch"); err == nil {
+		t.Errorf("unknown rule pack loaded")
+	}
+}
+
+func TestRulePackRules(t *testing.T) {
+	tests := []struct {
+		pack string
+		text string
+		rule string
+	}{
+		{"cliches", "At the end of the day, she left.", "CLICHE001"},
+		{"cliches", "Her heart skipped a beat.", "CLICHE002"},
+		{"cliches", "He let out a breath he didn't know he was holding.", "CLICHE003"},
+		{"house", "It was alright.", "HOUSE001"},
+		{"house", "She said OK and left.", "HOUSE002"},
+		{"house", "Anyways, she left.", "HOUSE003"},
+		{"house", "Irregardless, she left.", "HOUSE004"},
+		{"house", "She could of left.", "HOUSE005"},
+		{"house", "She will try and leave.", "HOUSE006"},
+		{"house", "She left at 9 sharp.", "HOUSE007"},
+		{"redundancies", "He nodded his head.", "REDUNDANT001"},
+		{"redundancies", "She whispered softly.", "REDUNDANT002"},
+		{"redundancies", "It was the end result of it all.", "REDUNDANT003"},
+		{"redundancies", "She checked each and every door.", "REDUNDANT003"},
+	}
+	covered := make(map[string]bool)
+	for _, test := range tests {
+		s, err := RulePack(test.pack)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// Some rules are off unless enabled.
+		s.Enable = []string{test.rule}
+		found := false
+		for _, f := range s.Lint(test.text + "\n") {
+			found = found || f.Rule == test.rule
+		}
+		if !found {
+			t.Errorf("%v: %q does not find %v", test.pack, test.text, test.rule)
+		}
+		covered[test.rule] = true
+	}
+	for _, name := range RulePacks() {
+		s, _ := RulePack(name)
+		for _, r := range s.Rules {
+			if !covered[r.ID] {
+				t.Errorf("%v has no test", r.ID)
+			}
+		}
+	}
+}
+
+func TestRuleSetLint(t *testing.T) {
+	rules := "rules:\n" +
+		"  - id: R1\n    tokens: very * indeed\n    message: Cut \"$0\"\n    suggestion: indeed\n" +
+		"  - id: R2\n    pattern: '(?i)\\bcould of\\b'\n    scope: sentence\n    severity: error\n" +
+		"  - id: R3\n    tokens: suddenly\n    disabled: true\n" +
+		"  - id: R4\n    tokens: end here\n    scope: line\n"
+	tests := []struct {
+		name     string
+		text     string
+		enable   []string
+		disable  []string
+		findings []string
+		fixed    string
+	}{
+		{
+			"tokens with a wildcard, across a line break",
+			"It was very\ncold indeed, suddenly.\n",
+			nil, nil,
+			[]string{"R1@1:8"},
+			"It was indeed, suddenly.\n",
+		},
+		{
+			"pattern",
+			"She could of gone.\n",
+			nil, nil,
+			[]string{"R2@1:5"},
+			"She could of gone.\n",
+		},
+		{
+			"disabled rule enabled",
+			"It was suddenly dark.\n",
+			[]string{"R3"}, nil,
+			[]string{"R3@1:8"},
+			"It was suddenly dark.\n",
+		},
+		{
+			"rule disabled",
+			"She could of gone  away.\n",
+			nil, []string{"R2", "TYPO001"},
+			[]string{},
+			"She could of gone  away.\n",
+		},
+		{
+			"line scope",
+			"We end\nhere. We end here.\n",
+			nil, nil,
+			[]string{"R4@2:10"},
+			"We end\nhere. We end here.\n",
+		},
+		{
+			"disable and enable",
+			"She could of gone.\n<!-- booktools-disable R2 -->\nShe could of gone.\n<!-- booktools-enable R2 -->\nShe could of gone.\n",
+			nil, nil,
+			[]string{"R2@1:5", "R2@5:5"},
+			"She could of gone.\n<!-- booktools-disable R2 -->\nShe could of gone.\n<!-- booktools-enable R2 -->\nShe could of gone.\n",
+		},
+		{
+			"disable every rule",
+			"<!-- booktools-disable -->\nShe could of  gone.\n<!-- booktools-enable -->\nIt was very cold indeed.\n",
+			nil, nil,
+			[]string{"R1@4:8"},
+			"<!-- booktools-disable -->\nShe could of  gone.\n<!-- booktools-enable -->\nIt was indeed.\n",
+		},
+		{
+			"disable this line",
+			"She could of  gone. <!-- booktools-disable-line R2 -->\n",
+			nil, nil,
+			[]string{"TYPO001@1:13"},
+			"She could of gone. <!-- booktools-disable-line R2 -->\n",
+		},
+		{
+			"disable the next line",
+			"<!-- booktools-disable-next-line R2, TYPO001 -->\nShe could of  gone.\nShe could of gone.\n",
+			nil, nil,
+			[]string{"R2@3:5"},
+			"<!-- booktools-disable-next-line R2, TYPO001 -->\nShe could of  gone.\nShe could of gone.\n",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s, err := LoadRules(strings.NewReader(rules))
+			if err != nil {
+				t.Fatal(err)
+			}
+			s.Enable, s.Disable = test.enable, test.disable
+			l := s.Lint(test.text)
+			if keys := findingKeys(l); !reflect.DeepEqual(keys, test.findings) {
+				t.Errorf("findings %v, want %v", keys, test.findings)
+			}
+			if fixed := ApplyFixes(test.text, l); fixed != test.fixed {
+				t.Errorf("fixed %q, want %q", fixed, test.fixed)
+			}
+		})
+	}
+}
+
+func TestRuleSetAdd(t *testing.T) {
+	s, _ := LoadRules(strings.NewReader("rules:\n  - id: R1\n    tokens: very\n  - id: R2\n    tokens: quite\n"))
+	o, _ := LoadRules(strings.NewReader("rules:\n  - id: R2\n    tokens: rather\n  - id: R3\n    tokens: so\ndisable: [R1]\n"))
+	s.Add(o)
+	ids := make([]string, len(s.Rules))
+	for i, r := range s.Rules {
+		ids[i] = r.ID + " " + r.Tokens
+	}
+	if want := []string{"R1 very", "R2 rather", "R3 so"}; !reflect.DeepEqual(ids, want) {
+		t.Errorf("rules %v, want %v", ids, want)
+	}
+	if s.enabled("R1", false) {
+		t.Errorf("R1 enabled after it was disabled")
+	}
+}
+
+func TestChunkerSuppression(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		// same is the text which should chunk into the same words,
+		// sentences and paragraphs.
+		same string
+	}{
+		{
+			"own line between paragraphs",
+			"Chapter 1\n\nAnna slept.\n\n<!-- booktools-disable TYPO001 -->\n\nBen woke.\n",
+			"Chapter 1\n\nAnna slept.\n\nBen woke.\n",
+		},
+		{
+			"own line within a paragraph",
+			"Chapter 1\n\nAnna slept\n<!-- booktools-disable-next-line -->\nand Ben woke.\n",
+			"Chapter 1\n\nAnna slept\nand Ben woke.\n",
+		},
+		{
+			"end of a line",
+			"Chapter 1\n\nAnna slept. <!-- booktools-disable-line TYPO004 -->\nBen woke.\n",
+			"Chapter 1\n\nAnna slept.\nBen woke.\n",
+		},
+		{
+			"start of a section",
+			"Chapter 1\n\n<!-- booktools-disable -->\n<!--\npov: Anna\n-->\n\nAnna slept.\n",
+			"Chapter 1\n\n<!--\npov: Anna\n-->\n\nAnna slept.\n",
+		},
+		{
+			"other comment",
+			"Chapter 1\n\nAnna slept. <!-- a note -->\n",
+			"Chapter 1\n\nAnna slept. <!-- a note -->\n",
+		},
+	}
+	// words lists the words of a tree by sentence and paragraph.
+	words := func(root *Chunk) []string {
+		l := make([]string, 0)
+		root.Walk(Sentence, func(a Address, s *Chunk) bool {
+			w := make([]string, len(s.Children))
+			for i, c := range s.Children {
+				w[i] = c.Word
+			}
+			l = append(l, a.String()+" "+strings.Join(w, " "))
+			return true
+		})
+		return l
+	}
+	for _, test := range tests {
+		for ending, text := range lineEndings(test.text) {
+			t.Run(test.name+"/"+ending, func(t *testing.T) {
+				root := Parse(text, nil)
+				want := Parse(lineEndings(test.same)[ending], nil)
+				if got, want := words(root), words(want); !reflect.DeepEqual(got, want) {
+					t.Errorf("words %q, want %q", got, want)
+				}
+				if !reflect.DeepEqual(Scenes(root)[0].Meta, Scenes(want)[0].Meta) {
+					t.Errorf("meta %v, want %v", Scenes(root)[0].Meta, Scenes(want)[0].Meta)
+				}
+				if n := CharacterFrequencies(root, 0, 0)["booktools"]; n > 0 {
+					t.Errorf("booktools counted as a character %d times", n)
+				}
+			})
+		}
+	}
+}
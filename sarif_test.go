@@ -0,0 +1,85 @@
+package booktools
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var reportFindings = LintFindings{
+	{File: "a.txt", Rule: "TYPO001", Severity: SeverityWarning, Line: 3, Column: 8, Message: "Double space"},
+	{File: "b.txt", Rule: "ECHO001", Severity: SeverityInfo, Line: 1, Column: 2, Message: "walked echoes walked"},
+	{File: "a.txt", Rule: "TYPO001", Severity: SeverityWarning, Line: 5, Column: 1, Message: "Double space & more"},
+}
+
+func TestWriteSARIF(t *testing.T) {
+	buf := &bytes.Buffer{}
+	if err := WriteSARIF(buf, reportFindings); err != nil {
+		t.Fatal(err)
+	}
+	var log sarifLog
+	if err := json.Unmarshal(buf.Bytes(), &log); err != nil {
+		t.Fatal(err)
+	}
+	if log.Version != "2.1.0" || len(log.Runs) != 1 {
+		t.Fatalf("version %v with %d runs", log.Version, len(log.Runs))
+	}
+	run := log.Runs[0]
+	rules := make([]string, len(run.Tool.Driver.Rules))
+	for i, r := range run.Tool.Driver.Rules {
+		rules[i] = r.ID + " " + r.DefaultConfiguration.Level
+	}
+	if want := []string{"TYPO001 warning", "ECHO001 note"}; !reflect.DeepEqual(rules, want) {
+		t.Errorf("rules %q, want %q", rules, want)
+	}
+	results := make([]string, len(run.Results))
+	for i, r := range run.Results {
+		l := r.Locations[0].PhysicalLocation
+		results[i] = r.RuleID + " " + run.Tool.Driver.Rules[r.RuleIndex].ID + " " + l.ArtifactLocation.URI + " " + r.Message.Text
+		if l.Region.StartLine != reportFindings[i].Line || l.Region.StartColumn != reportFindings[i].Column {
+			t.Errorf("result %d at %d:%d, want %d:%d", i, l.Region.StartLine, l.Region.StartColumn, reportFindings[i].Line, reportFindings[i].Column)
+		}
+	}
+	want := []string{"TYPO001 TYPO001 a.txt Double space", "ECHO001 ECHO001 b.txt walked echoes walked", "TYPO001 TYPO001 a.txt Double space & more"}
+	if !reflect.DeepEqual(results, want) {
+		t.Errorf("results %q, want %q", results, want)
+	}
+}
+
+func TestWriteCheckstyle(t *testing.T) {
+	buf := &bytes.Buffer{}
+	if err := WriteCheckstyle(buf, reportFindings); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(buf.String(), xml.Header) {
+		t.Errorf("no XML header in %q", buf.String())
+	}
+	var log checkstyleLog
+	if err := xml.Unmarshal(buf.Bytes(), &log); err != nil {
+		t.Fatal(err)
+	}
+	files := make([]string, 0)
+	for _, f := range log.Files {
+		for _, e := range f.Errors {
+			files = append(files, f.Name+" "+e.Source+" "+e.Severity+" "+e.Message)
+		}
+	}
+	want := []string{"a.txt booktools.TYPO001 warning Double space", "a.txt booktools.TYPO001 warning Double space & more", "b.txt booktools.ECHO001 info walked echoes walked"}
+	if !reflect.DeepEqual(files, want) {
+		t.Errorf("errors %q, want %q", files, want)
+	}
+}
+
+func TestWriteResultDiagnostic(t *testing.T) {
+	for _, format := range []string{"sarif", "checkstyle"} {
+		if err := WriteResult(&bytes.Buffer{}, reportFindings, format); err != nil {
+			t.Errorf("%v: %v", format, err)
+		}
+		if err := WriteResult(&bytes.Buffer{}, SearchResults{}, format); err == nil {
+			t.Errorf("%v: search results written", format)
+		}
+	}
+}
@@ -0,0 +1,344 @@
+package booktools
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+func init() {
+	RegisterAnalyzer(SceneAnalyzer{})
+}
+
+// metaLine matches a line of metadata naming a field, and metaItem one
+// of a list of values beneath it.
+var metaLine = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_-]*:(\s|$)`)
+var metaItem = regexp.MustCompile(`^\s*- `)
+
+// parseMeta reads a block of section metadata, which must be a YAML
+// mapping of names to values or lists of values. Names are lower cased,
+// and lists joined with commas. So that a line of prose such as "The
+// note said: come home" is not taken for metadata, each line must start
+// with a name of one word, or be an item of a list beneath one.
+func parseMeta(text string) (map[string]string, bool) {
+	for i, line := range strings.Split(strings.TrimRight(text, "\r\n"), "\n") {
+		if !metaLine.MatchString(line) && (i == 0 || !metaItem.MatchString(line)) {
+			return nil, false
+		}
+	}
+	var m yaml.MapSlice
+	if err := yaml.Unmarshal([]byte(text), &m); err != nil || len(m) == 0 {
+		return nil, false
+	}
+	meta := make(map[string]string)
+	for _, item := range m {
+		key, ok := item.Key.(string)
+		if !ok {
+			return nil, false
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		switch v := item.Value.(type) {
+		case nil:
+			meta[key] = ""
+		case []interface{}:
+			values := make([]string, len(v))
+			for i, value := range v {
+				values[i] = fmt.Sprint(value)
+			}
+			meta[key] = strings.Join(values, ", ")
+		case yaml.MapSlice:
+			return nil, false
+		default:
+			meta[key] = fmt.Sprint(v)
+		}
+	}
+	return meta, true
+}
+
+// MetaValues splits a metadata value into the values of its list.
+func MetaValues(value string) []string {
+	values := make([]string, 0)
+	for _, v := range strings.Split(value, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+// Scene is a section of a work with its metadata.
+type Scene struct {
+	Address Address           `json:"address" yaml:"address"`
+	Words   int               `json:"words" yaml:"words"`
+	Opening string            `json:"opening" yaml:"opening"`
+	Meta    map[string]string `json:"meta" yaml:"meta"`
+}
+
+// SceneFilter matches the scenes whose Field has Value among its values,
+// or where Exclude is set, those which do not. An empty Value matches
+// the scenes with any value for Field at all.
+type SceneFilter struct {
+	Field   string
+	Value   string
+	Exclude bool
+}
+
+// ParseSceneFilter parses a filter written field=value, field!=value,
+// field or !field.
+func ParseSceneFilter(s string) (SceneFilter, error) {
+	f := SceneFilter{}
+	if i := strings.Index(s, "="); i >= 0 {
+		f.Field, f.Value = s[:i], strings.TrimSpace(s[i+1:])
+		if strings.HasSuffix(f.Field, "!") {
+			f.Field, f.Exclude = strings.TrimSuffix(f.Field, "!"), true
+		}
+		if f.Value == "" {
+			return f, fmt.Errorf("filter %q has no value", s)
+		}
+	} else {
+		f.Field = s
+		if strings.HasPrefix(f.Field, "!") {
+			f.Field, f.Exclude = strings.TrimPrefix(f.Field, "!"), true
+		}
+	}
+	f.Field = strings.ToLower(strings.TrimSpace(f.Field))
+	if f.Field == "" {
+		return f, fmt.Errorf("filter %q has no field", s)
+	}
+	return f, nil
+}
+
+// Match reports whether the scene passes the filter.
+func (f SceneFilter) Match(s Scene) bool {
+	value, ok := s.Meta[f.Field]
+	found := ok && value != ""
+	if f.Value != "" {
+		found = false
+		for _, v := range MetaValues(value) {
+			if strings.EqualFold(v, f.Value) {
+				found = true
+			}
+		}
+	}
+	return found != f.Exclude
+}
+
+func (f SceneFilter) String() string {
+	switch {
+	case f.Value == "" && f.Exclude:
+		return "!" + f.Field
+	case f.Value == "":
+		return f.Field
+	case f.Exclude:
+		return f.Field + "!=" + f.Value
+	}
+	return f.Field + "=" + f.Value
+}
+
+// SceneList is the scenes of a work, in order.
+type SceneList []Scene
+
+// Scenes lists the sections beneath the work root.
+func Scenes(root *Chunk) SceneList {
+	l := make(SceneList, 0)
+	root.Walk(Section, func(a Address, c *Chunk) bool {
+		meta := c.Meta
+		if meta == nil {
+			meta = map[string]string{}
+		}
+		l = append(l, Scene{
+			Address: a,
+			Words:   c.GetWordCount(),
+			Opening: abbreviate(strings.TrimSpace(c.GetFirstSentence()), 60),
+			Meta:    meta,
+		})
+		return true
+	})
+	return l
+}
+
+// Filter returns the scenes which pass every filter.
+func (l SceneList) Filter(filters ...SceneFilter) SceneList {
+	matched := make(SceneList, 0)
+	for _, s := range l {
+		ok := true
+		for _, f := range filters {
+			ok = ok && f.Match(s)
+		}
+		if ok {
+			matched = append(matched, s)
+		}
+	}
+	return matched
+}
+
+// Fields returns the names of the metadata of the scenes, sorted.
+func (l SceneList) Fields() []string {
+	seen := make(map[string]bool)
+	fields := make([]string, 0)
+	for _, s := range l {
+		for k := range s.Meta {
+			if !seen[k] {
+				seen[k] = true
+				fields = append(fields, k)
+			}
+		}
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+// Words is the word count of the scenes together.
+func (l SceneList) Words() int {
+	n := 0
+	for _, s := range l {
+		n = n + s.Words
+	}
+	return n
+}
+
+func (l SceneList) Columns() []string {
+	return append(append([]string{"Address", "Words"}, l.Fields()...), "Opening")
+}
+
+func (l SceneList) Rows() [][]string {
+	fields := l.Fields()
+	rows := make([][]string, len(l))
+	for i, s := range l {
+		row := []string{s.Address.String(), strconv.Itoa(s.Words)}
+		for _, f := range fields {
+			row = append(row, s.Meta[f])
+		}
+		rows[i] = append(row, s.Opening)
+	}
+	return rows
+}
+
+func (l SceneList) String() string {
+	fields := l.Fields()
+	sb := strings.Builder{}
+	for _, s := range l {
+		meta := make([]string, 0, len(fields))
+		for _, f := range fields {
+			if v, ok := s.Meta[f]; ok {
+				meta = append(meta, f+": "+v)
+			}
+		}
+		sb.WriteString(fmt.Sprintf("%-6v %6d  %v\n", s.Address, s.Words, s.Opening))
+		if len(meta) > 0 {
+			sb.WriteString(fmt.Sprintf("       %v\n", strings.Join(meta, "; ")))
+		}
+	}
+	return sb.String()
+}
+
+// SceneGroup is the scenes sharing one value of a field.
+type SceneGroup struct {
+	Value  string    `json:"value" yaml:"value"`
+	Scenes SceneList `json:"scenes" yaml:"scenes"`
+	Words  int       `json:"words" yaml:"words"`
+}
+
+// SceneGroups is the scenes of a work grouped by the values of a field.
+type SceneGroups struct {
+	Field  string       `json:"field" yaml:"field"`
+	Groups []SceneGroup `json:"groups" yaml:"groups"`
+}
+
+// Group sorts the scenes into groups by their values of field, most
+// words first, with the scenes which have none last, in a group whose
+// Value is "". A scene with a list of values is in the group of each.
+func (l SceneList) Group(field string) *SceneGroups {
+	field = strings.ToLower(field)
+	groups := make(map[string]*SceneGroup)
+	order := make([]string, 0)
+	add := func(key string, value string, s Scene) {
+		g, ok := groups[key]
+		if !ok {
+			g = &SceneGroup{Value: value, Scenes: make(SceneList, 0)}
+			groups[key] = g
+			order = append(order, key)
+		}
+		g.Scenes = append(g.Scenes, s)
+		g.Words = g.Words + s.Words
+	}
+	for _, s := range l {
+		values := MetaValues(s.Meta[field])
+		if len(values) == 0 {
+			add("", "", s)
+		}
+		for _, v := range values {
+			add(strings.ToLower(v), v, s)
+		}
+	}
+	result := &SceneGroups{Field: field, Groups: make([]SceneGroup, 0, len(order))}
+	for _, k := range order {
+		result.Groups = append(result.Groups, *groups[k])
+	}
+	sort.SliceStable(result.Groups, func(i, j int) bool {
+		a, b := result.Groups[i], result.Groups[j]
+		if (a.Value == "") != (b.Value == "") {
+			return b.Value == ""
+		}
+		return a.Words > b.Words
+	})
+	return result
+}
+
+func (g *SceneGroups) Columns() []string {
+	return []string{strings.Title(g.Field), "Scenes", "Words", "Addresses"}
+}
+
+func (g *SceneGroups) Rows() [][]string {
+	rows := make([][]string, len(g.Groups))
+	for i, group := range g.Groups {
+		addresses := make([]string, len(group.Scenes))
+		for j, s := range group.Scenes {
+			addresses[j] = s.Address.String()
+		}
+		rows[i] = []string{group.Value, strconv.Itoa(len(group.Scenes)), strconv.Itoa(group.Words), strings.Join(addresses, " ")}
+	}
+	return rows
+}
+
+func (g *SceneGroups) String() string {
+	sb := strings.Builder{}
+	for _, group := range g.Groups {
+		value := group.Value
+		if value == "" {
+			value = "(no " + g.Field + ")"
+		}
+		addresses := make([]string, len(group.Scenes))
+		for j, s := range group.Scenes {
+			addresses[j] = s.Address.String()
+		}
+		n := len(group.Scenes)
+		sb.WriteString(fmt.Sprintf("%-20v %3d %-6v %7d words  %v\n", value, n, plural(n, "scene"), group.Words, strings.Join(addresses, " ")))
+	}
+	return sb.String()
+}
+
+// SceneAnalyzer lists the scenes which pass the Where filters, grouped
+// by the values of GroupBy where it is set.
+type SceneAnalyzer struct {
+	Where   []SceneFilter
+	GroupBy string
+}
+
+func (a SceneAnalyzer) Name() string { return "scenes" }
+func (a SceneAnalyzer) Description() string {
+	return "Lists the sections with their metadata, such as point of view and location"
+}
+func (a SceneAnalyzer) Unit() int { return Section }
+
+func (a SceneAnalyzer) Analyze(c *Chunk) Result {
+	l := Scenes(c).Filter(a.Where...)
+	if a.GroupBy != "" {
+		return l.Group(a.GroupBy)
+	}
+	return l
+}
@@ -0,0 +1,229 @@
+package booktools
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// SearchOptions control how Search matches its query.
+type SearchOptions struct {
+	// Regexp treats the query as a regular expression matched against
+	// the text of each paragraph, rather than as a word or phrase.
+	Regexp bool
+	// MatchCase and MatchDiacritics make matching sensitive to case
+	// and to accents, which are otherwise ignored.
+	MatchCase       bool
+	MatchDiacritics bool
+	// Stem matches any inflection of the query words, so that "walk"
+	// finds "walked" and "walking".
+	Stem bool
+	// Context is the number of words shown either side of each match.
+	Context int
+}
+
+// SearchHit is one match, as a keyword-in-context line.
+type SearchHit struct {
+	Address Address `json:"address" yaml:"address"`
+	Left    string  `json:"left" yaml:"left"`
+	Match   string  `json:"match" yaml:"match"`
+	Right   string  `json:"right" yaml:"right"`
+}
+
+// SearchResults lists the matches of a search in reading order.
+type SearchResults []SearchHit
+
+func (l SearchResults) Columns() []string { return []string{"Address", "Left", "Match", "Right"} }
+
+func (l SearchResults) Rows() [][]string {
+	rows := make([][]string, len(l))
+	for i, h := range l {
+		rows[i] = []string{h.Address.String(), h.Left, h.Match, h.Right}
+	}
+	return rows
+}
+
+func (l SearchResults) String() string {
+	sb := strings.Builder{}
+	for _, h := range l {
+		sb.WriteString(fmt.Sprintf("%-10v %50v [%v] %v\n", h.Address, lastRunes(h.Left, 50), h.Match, h.Right))
+	}
+	return sb.String()
+}
+
+// Search finds query in every paragraph beneath the work root.
+func Search(root *Chunk, query string, opts SearchOptions) (SearchResults, error) {
+	m, err := newWordMatcher(query, opts)
+	if err != nil {
+		return nil, err
+	}
+	results := make(SearchResults, 0)
+	root.Walk(Paragraph, func(a Address, p *Chunk) bool {
+		words, sentences := paragraphWords(p)
+		for _, s := range m.match(words) {
+			hit := a
+			hit.Sentence = sentences[s.start]
+			results = append(results, kwic(hit, words, s, opts.Context))
+		}
+		return true
+	})
+	return results, nil
+}
+
+// span is a run of words, from start up to but not including end.
+type span struct {
+	start int
+	end   int
+}
+
+// wordMatcher finds a query within the words of a paragraph.
+type wordMatcher struct {
+	opts SearchOptions
+	keys []string
+	re   *regexp.Regexp
+}
+
+func newWordMatcher(query string, opts SearchOptions) (*wordMatcher, error) {
+	m := &wordMatcher{opts: opts}
+	if opts.Regexp {
+		expr := query
+		if !opts.MatchDiacritics {
+			expr = FoldDiacritics(expr)
+		}
+		if !opts.MatchCase {
+			expr = "(?i)" + expr
+		}
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("could not compile search expression [%v]: %v", query, err)
+		}
+		m.re = re
+		return m, nil
+	}
+	for _, w := range strings.Fields(query) {
+		if k := m.key(w); k != "" {
+			m.keys = append(m.keys, k)
+		}
+	}
+	if len(m.keys) == 0 {
+		return nil, fmt.Errorf("nothing to search for in [%v]", query)
+	}
+	return m, nil
+}
+
+// key reduces a word to the form in which it is compared.
+func (m *wordMatcher) key(w string) string {
+	w = TrimPunctuation(strings.Replace(w, "’", "'", -1))
+	if !m.opts.MatchDiacritics {
+		w = FoldDiacritics(w)
+	}
+	if !m.opts.MatchCase {
+		w = strings.ToLower(w)
+	}
+	if m.opts.Stem {
+		w = Stem(w)
+	}
+	return w
+}
+
+func (m *wordMatcher) match(words []*Chunk) []span {
+	if m.re != nil {
+		return m.matchRegexp(words)
+	}
+	spans := make([]span, 0)
+	keys := make([]string, len(words))
+	for i, w := range words {
+		keys[i] = m.key(w.Word)
+	}
+	for i := 0; i+len(m.keys) <= len(keys); i++ {
+		found := true
+		for j, k := range m.keys {
+			if keys[i+j] != k {
+				found = false
+				break
+			}
+		}
+		if found {
+			spans = append(spans, span{i, i + len(m.keys)})
+		}
+	}
+	return spans
+}
+
+// matchRegexp matches against the words joined by single spaces, and
+// widens each match to the words it touches.
+func (m *wordMatcher) matchRegexp(words []*Chunk) []span {
+	spans := make([]span, 0)
+	starts := make([]int, len(words))
+	sb := strings.Builder{}
+	for i, w := range words {
+		if i > 0 {
+			sb.WriteString(" ")
+		}
+		starts[i] = sb.Len()
+		if m.opts.MatchDiacritics {
+			sb.WriteString(w.Word)
+		} else {
+			sb.WriteString(FoldDiacritics(w.Word))
+		}
+	}
+	wordAt := func(pos int) int {
+		return sort.Search(len(starts), func(i int) bool { return starts[i] > pos }) - 1
+	}
+	for _, loc := range m.re.FindAllStringIndex(sb.String(), -1) {
+		if loc[1] == loc[0] {
+			continue
+		}
+		spans = append(spans, span{wordAt(loc[0]), wordAt(loc[1]-1) + 1})
+	}
+	return spans
+}
+
+// paragraphWords lists the words of a paragraph, and the number of the
+// sentence, counting from 1, in which each word falls.
+func paragraphWords(p *Chunk) ([]*Chunk, []int) {
+	words := make([]*Chunk, 0)
+	sentences := make([]int, 0)
+	for i, s := range p.Children {
+		for _, w := range s.Children {
+			words = append(words, w)
+			sentences = append(sentences, i+1)
+		}
+	}
+	return words, sentences
+}
+
+func kwic(a Address, words []*Chunk, s span, context int) SearchHit {
+	from := s.start - context
+	if from < 0 {
+		from = 0
+	}
+	to := s.end + context
+	if to > len(words) {
+		to = len(words)
+	}
+	return SearchHit{
+		Address: a,
+		Left:    joinWords(words[from:s.start]),
+		Match:   joinWords(words[s.start:s.end]),
+		Right:   joinWords(words[s.end:to]),
+	}
+}
+
+func joinWords(words []*Chunk) string {
+	s := make([]string, len(words))
+	for i, w := range words {
+		s[i] = w.Word
+	}
+	return strings.Join(s, " ")
+}
+
+// lastRunes returns at most the last n runes of s.
+func lastRunes(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[len(r)-n:])
+}
@@ -0,0 +1,48 @@
+package booktools
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSearch(t *testing.T) {
+	text := "Chapter 1\n\nAnna walked home. Walking was her habit.\n\nBen walks to the café. He walked in the Cafe.\n"
+	tests := []struct {
+		name    string
+		query   string
+		opts    SearchOptions
+		matches []string
+	}{
+		{"word", "walked", SearchOptions{}, []string{"1.1.2.1 walked", "1.1.3.2 walked"}},
+		{"ignores case", "WALKED", SearchOptions{}, []string{"1.1.2.1 walked", "1.1.3.2 walked"}},
+		{"match case", "Walking", SearchOptions{MatchCase: true}, []string{"1.1.2.2 Walking"}},
+		{"stem", "walk", SearchOptions{Stem: true}, []string{"1.1.2.1 walked", "1.1.2.2 Walking", "1.1.3.1 walks", "1.1.3.2 walked"}},
+		{"stem and match case", "Walk", SearchOptions{Stem: true, MatchCase: true}, []string{"1.1.2.2 Walking"}},
+		{"stem and match case, lower", "walks", SearchOptions{Stem: true, MatchCase: true}, []string{"1.1.2.1 walked", "1.1.3.1 walks", "1.1.3.2 walked"}},
+		{"phrase", "walked home", SearchOptions{}, []string{"1.1.2.1 walked home."}},
+		{"diacritics", "cafe", SearchOptions{}, []string{"1.1.3.1 café.", "1.1.3.2 Cafe."}},
+		{"match diacritics", "cafe", SearchOptions{MatchDiacritics: true}, []string{"1.1.3.2 Cafe."}},
+		{"regexp", `walk(ed|s)\b`, SearchOptions{Regexp: true}, []string{"1.1.2.1 walked", "1.1.3.1 walks", "1.1.3.2 walked"}},
+	}
+	root := Parse(text, nil)
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			l, err := Search(root, test.query, test.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			matches := make([]string, len(l))
+			for i, h := range l {
+				matches[i] = h.Address.String() + " " + h.Match
+			}
+			if !reflect.DeepEqual(matches, test.matches) {
+				t.Errorf("matches %q, want %q", matches, test.matches)
+			}
+		})
+	}
+	for _, query := range []string{"", " , ", "(walk"} {
+		if _, err := Search(root, query, SearchOptions{Regexp: query == "(walk"}); err == nil {
+			t.Errorf("Search(%q) is not an error", query)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package booktools
+
+// ChunkStats counts the chunks of each unit beneath a Chunk.
+type ChunkStats struct {
+	Words      int `json:"words" yaml:"words"`
+	Sentences  int `json:"sentences" yaml:"sentences"`
+	Paragraphs int `json:"paragraphs" yaml:"paragraphs"`
+	Sections   int `json:"sections" yaml:"sections"`
+}
+
+// Stats counts the words, sentences, paragraphs and sections beneath c.
+func (c *Chunk) Stats() ChunkStats {
+	s := ChunkStats{}
+	iter := NewChunkIterator(c)
+	for iter.NextChunk() != nil {
+		switch iter.Value().Unit {
+		case Word:
+			s.Words = s.Words + 1
+		case Sentence:
+			s.Sentences = s.Sentences + 1
+		case Paragraph:
+			s.Paragraphs = s.Paragraphs + 1
+		case Section:
+			s.Sections = s.Sections + 1
+		}
+	}
+	return s
+}
+
+// Chapters returns the chapters beneath c, in order.
+func (c *Chunk) Chapters() []*Chunk {
+	return c.Find(Chapter)
+}
+
+// Find returns every chunk of the given unit beneath c, in order.
+func (c *Chunk) Find(unit int) []*Chunk {
+	l := make([]*Chunk, 0)
+	iter := NewChunkIterator(c)
+	for iter.NextChunk() != nil {
+		if iter.Value().Unit == unit {
+			l = append(l, iter.Value())
+		}
+	}
+	return l
+}
+
+// GetChapterChunk returns the chapter'th chapter, counting from 1, or
+// nil if there is no such chapter.
+func (c *Chunk) GetChapterChunk(chapter int) *Chunk {
+	chapters := c.Chapters()
+	if chapter < 1 || chapter > len(chapters) {
+		return nil
+	}
+	return chapters[chapter-1]
+}
@@ -0,0 +1,88 @@
+package booktools
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Stem reduces an English word to an approximate stem, so that "walks",
+// "walked" and "walking" all become "walk". It applies the plural and
+// past-tense steps of the Porter stemmer, which is enough to match the
+// common inflections of a word without the cost of a full stemmer.
+//
+// The stem keeps the case of the word, so "Walked" becomes "Walk";
+// callers which ignore case lower the word first.
+func Stem(w string) string {
+	l := strings.ToLower(w)
+	s := stem(l)
+	if len(l) != len(w) {
+		// Lowering changed the length, so the cases cannot be matched
+		// up byte for byte.
+		return s
+	}
+	n := 0
+	for n < len(s) && n < len(l) && s[n] == l[n] {
+		n++
+	}
+	for n > 0 && n < len(w) && !utf8.RuneStart(w[n]) {
+		n--
+	}
+	if r, _ := utf8.DecodeLastRuneInString(w[:n]); unicode.IsUpper(r) {
+		return w[:n] + strings.ToUpper(s[n:])
+	}
+	return w[:n] + s[n:]
+}
+
+// stem stems a lowercase word.
+func stem(w string) string {
+	if len(w) <= 3 {
+		return w
+	}
+
+	// Plurals
+	switch {
+	case strings.HasSuffix(w, "sses"):
+		w = w[0 : len(w)-2]
+	case strings.HasSuffix(w, "ies"):
+		w = w[0 : len(w)-2]
+	case strings.HasSuffix(w, "ss"):
+	case strings.HasSuffix(w, "s"):
+		w = w[0 : len(w)-1]
+	}
+
+	// Past tense and gerunds
+	trimmed := false
+	switch {
+	case strings.HasSuffix(w, "eed"):
+		if len(w) > 4 {
+			w = w[0 : len(w)-1]
+		}
+	case strings.HasSuffix(w, "ed") && hasVowel(w[0:len(w)-2]):
+		w = w[0 : len(w)-2]
+		trimmed = true
+	case strings.HasSuffix(w, "ing") && hasVowel(w[0:len(w)-3]):
+		w = w[0 : len(w)-3]
+		trimmed = true
+	}
+	if trimmed {
+		switch {
+		case strings.HasSuffix(w, "at"), strings.HasSuffix(w, "bl"), strings.HasSuffix(w, "iz"):
+			w = w + "e"
+		case len(w) > 2 && w[len(w)-1] == w[len(w)-2] && !strings.ContainsAny(w[len(w)-1:], "aeiouylsz"):
+			w = w[0 : len(w)-1]
+		}
+	}
+
+	if strings.HasSuffix(w, "ly") && len(w) > 4 {
+		w = w[0 : len(w)-2]
+	}
+	if strings.HasSuffix(w, "y") && hasVowel(w[0:len(w)-1]) {
+		w = w[0:len(w)-1] + "i"
+	}
+	return w
+}
+
+func hasVowel(s string) bool {
+	return strings.ContainsAny(s, "aeiouy")
+}
@@ -0,0 +1,36 @@
+package booktools
+
+import "testing"
+
+func TestStem(t *testing.T) {
+	tests := []struct {
+		word string
+		stem string
+	}{
+		{"walk", "walk"},
+		{"walks", "walk"},
+		{"walked", "walk"},
+		{"walking", "walk"},
+		{"caresses", "caress"},
+		{"ponies", "poni"},
+		{"agreed", "agree"},
+		{"created", "create"},
+		{"hopping", "hop"},
+		{"falling", "fall"},
+		{"quickly", "quick"},
+		{"happy", "happi"},
+		{"sing", "sing"},
+		{"red", "red"},
+		{"Walked", "Walk"},
+		{"WALKED", "WALK"},
+		{"CREATED", "CREATE"},
+		{"HAPPY", "HAPPI"},
+		{"Élan", "Élan"},
+		{"Éclairs", "Éclair"},
+	}
+	for _, test := range tests {
+		if got := Stem(test.word); got != test.stem {
+			t.Errorf("Stem(%q) = %q, want %q", test.word, got, test.stem)
+		}
+	}
+}
@@ -0,0 +1,26 @@
+package booktools
+
+// StopWords are common English words which carry little meaning of
+// their own, and so are ignored when looking for repeated or overused
+// words.
+var StopWords = map[string]bool{}
+
+func init() {
+	for _, w := range []string{
+		"a", "about", "above", "after", "again", "against", "all", "am", "an", "and", "any", "are", "as", "at",
+		"be", "because", "been", "before", "being", "below", "between", "both", "but", "by",
+		"can", "could", "did", "do", "does", "doing", "down", "during",
+		"each", "few", "for", "from", "further",
+		"had", "has", "have", "having", "he", "her", "here", "hers", "herself", "him", "himself", "his", "how",
+		"i", "if", "in", "into", "is", "it", "its", "itself",
+		"just", "me", "more", "most", "my", "myself",
+		"no", "nor", "not", "now", "of", "off", "on", "once", "only", "or", "other", "our", "ours", "ourselves", "out", "over", "own",
+		"said", "same", "she", "should", "so", "some", "such",
+		"than", "that", "the", "their", "theirs", "them", "themselves", "then", "there", "these", "they", "this", "those", "through", "to", "too",
+		"under", "until", "up", "very",
+		"was", "we", "were", "what", "when", "where", "which", "while", "who", "whom", "why", "will", "with", "would",
+		"you", "your", "yours", "yourself", "yourselves",
+	} {
+		StopWords[w] = true
+	}
+}
@@ -0,0 +1,51 @@
+package booktools
+
+import (
+	"strconv"
+	"strings"
+)
+
+// StructureNode is one chunk in the outline of a work.
+type StructureNode struct {
+	Depth int    `json:"depth" yaml:"depth"`
+	Unit  string `json:"unit" yaml:"unit"`
+	Text  string `json:"text,omitempty" yaml:"text,omitempty"`
+}
+
+// StructureList is the outline of a work, in reading order.
+type StructureList []StructureNode
+
+func (l StructureList) Columns() []string { return []string{"Depth", "Unit", "Text"} }
+
+func (l StructureList) Rows() [][]string {
+	rows := make([][]string, len(l))
+	for i, n := range l {
+		rows[i] = []string{strconv.Itoa(n.Depth), n.Unit, n.Text}
+	}
+	return rows
+}
+
+func (l StructureList) String() string {
+	sb := strings.Builder{}
+	for _, n := range l {
+		for i := 0; i < n.Depth; i++ {
+			sb.WriteString("    ")
+		}
+		sb.WriteString("[" + n.Unit + "]" + n.Text + "\n")
+	}
+	return sb.String()
+}
+
+// ChapterText is the text of a single chapter.
+type ChapterText struct {
+	Chapter int    `json:"chapter" yaml:"chapter"`
+	Text    string `json:"text" yaml:"text"`
+}
+
+func (t ChapterText) Columns() []string { return []string{"Chapter", "Text"} }
+
+func (t ChapterText) Rows() [][]string {
+	return [][]string{{strconv.Itoa(t.Chapter), t.Text}}
+}
+
+func (t ChapterText) String() string { return t.Text + "\n" }
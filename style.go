@@ -0,0 +1,227 @@
+package booktools
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+func init() {
+	RegisterAnalyzer(StyleAnalyzer{})
+}
+
+// The kinds of StyleFinding.
+const (
+	StyleAdverb  = "adverb"
+	StyleFilter  = "filter"
+	StyleWeak    = "weak"
+	StylePassive = "passive"
+)
+
+// StyleKinds lists the kinds of StyleFinding in report order.
+var StyleKinds = []string{StyleAdverb, StyleFilter, StyleWeak, StylePassive}
+
+// AdverbExceptions are words ending in -ly which are not adverbs, or are
+// not worth flagging.
+var AdverbExceptions = wordSet(
+	"only", "early", "family", "reply", "supply", "apply", "rely", "ally", "rally", "fly", "july", "italy",
+	"holy", "ugly", "lovely", "friendly", "likely", "unlikely", "lonely", "silly", "belly", "bully", "jelly",
+	"butterfly", "assembly", "daily", "weekly", "monthly", "yearly", "elderly", "lively", "costly", "deadly",
+	"curly", "chilly", "hilly", "oily", "jolly", "holly", "lily", "kelly", "molly", "sally", "emily",
+	"melancholy", "anomaly", "monopoly", "multiply", "comply", "imply", "bodily", "gently", "wily", "surly",
+)
+
+// FilterWords put a character's perception between the reader and the
+// thing perceived.
+var FilterWords = wordSet(
+	"saw", "see", "sees", "seeing", "seen", "heard", "hear", "hears", "hearing",
+	"felt", "feel", "feels", "feeling", "noticed", "notice", "notices", "noticing",
+	"realized", "realize", "realizes", "realised", "realise", "realises",
+	"wondered", "watched", "looked", "seemed", "decided", "knew", "thought",
+)
+
+// WeakVerbs say little about what was done.
+var WeakVerbs = wordSet(
+	"got", "get", "gets", "getting", "went", "go", "goes", "make", "made", "makes",
+	"put", "puts", "do", "did", "does", "start", "started", "starts", "begin", "began", "begins",
+)
+
+var beForms = wordSet("am", "is", "are", "was", "were", "be", "been", "being")
+
+// irregularParticiples are past participles which do not end in -ed.
+var irregularParticiples = wordSet(
+	"taken", "given", "seen", "done", "made", "written", "known", "shown", "found", "held", "told",
+	"brought", "thought", "built", "caught", "kept", "lost", "sent", "spent", "struck", "torn", "worn",
+	"broken", "chosen", "driven", "eaten", "fallen", "forgotten", "hidden", "ridden", "shaken", "spoken",
+	"stolen", "woken", "beaten", "bitten", "born", "borne", "hurt", "hit", "cut", "shut", "thrown",
+	"drawn", "grown", "blown", "flown", "sworn", "sung", "rung", "hung", "swung", "stung", "paid", "said",
+	"sold", "bought", "fought", "taught", "led", "fed", "bound", "wound", "ground", "slain", "frozen",
+)
+
+// notParticiples end in -ed but are not past participles.
+var notParticiples = wordSet(
+	"need", "feed", "seed", "bed", "red", "shed", "speed", "bleed", "breed", "exceed", "proceed",
+	"succeed", "indeed", "hundred", "naked", "sacred", "wicked", "ragged", "rugged", "wretched",
+	"beloved", "crooked", "jagged", "kindred", "sled", "wed", "weed", "greed", "steed", "deed", "creed",
+)
+
+func wordSet(words ...string) map[string]bool {
+	m := make(map[string]bool)
+	for _, w := range words {
+		m[w] = true
+	}
+	return m
+}
+
+// StyleFinding is an adverb, filter word, weak verb or passive
+// construction in a sentence.
+type StyleFinding struct {
+	Address Address `json:"address" yaml:"address"`
+	Kind    string  `json:"kind" yaml:"kind"`
+	Text    string  `json:"text" yaml:"text"`
+
+	// Words are the words making up the finding.
+	Words []*Chunk `json:"-" yaml:"-"`
+}
+
+// StyleFindings finds adverbs, filter words, weak verbs and passive
+// constructions in a sentence.
+func StyleFindings(a Address, sentence *Chunk) []StyleFinding {
+	findings := make([]StyleFinding, 0)
+	words := sentence.Children
+	add := func(kind string, ws []*Chunk) {
+		findings = append(findings, StyleFinding{Address: a, Kind: kind, Text: joinWords(ws), Words: ws})
+	}
+	for i := 0; i < len(words); i++ {
+		k := WordKey(words[i].Word)
+		switch {
+		case beForms[k]:
+			// A form of "to be", perhaps with an adverb between it
+			// and a past participle
+			j := i + 1
+			if j < len(words) && isAdverb(WordKey(words[j].Word)) {
+				j = j + 1
+			}
+			if j < len(words) && isParticiple(WordKey(words[j].Word)) {
+				add(StylePassive, words[i:j+1])
+				i = j
+			}
+		case FilterWords[k]:
+			add(StyleFilter, words[i:i+1])
+		case WeakVerbs[k]:
+			add(StyleWeak, words[i:i+1])
+		case isAdverb(k) && !(i > 0 && isName(words[i].Word)):
+			add(StyleAdverb, words[i:i+1])
+		}
+	}
+	return findings
+}
+
+func isAdverb(k string) bool {
+	return len(k) > 4 && strings.HasSuffix(k, "ly") && !AdverbExceptions[k]
+}
+
+func isParticiple(k string) bool {
+	if irregularParticiples[k] {
+		return true
+	}
+	return len(k) > 3 && strings.HasSuffix(k, "ed") && !notParticiples[k]
+}
+
+func isName(w string) bool {
+	r, _ := utf8.DecodeRuneInString(TrimPunctuation(w))
+	return unicode.IsUpper(r)
+}
+
+// ChapterStyle totals the findings of each kind in a chapter.
+type ChapterStyle struct {
+	Chapter   int `json:"chapter" yaml:"chapter"`
+	Sentences int `json:"sentences" yaml:"sentences"`
+	// Flagged is the number of sentences with any finding.
+	Flagged  int            `json:"flagged" yaml:"flagged"`
+	Counts   map[string]int `json:"counts" yaml:"counts"`
+	Findings []StyleFinding `json:"findings,omitempty" yaml:"findings,omitempty"`
+}
+
+// StyleReport holds the style findings of every chapter.
+type StyleReport struct {
+	Chapters []ChapterStyle `json:"chapters" yaml:"chapters"`
+
+	detail bool
+}
+
+func (r *StyleReport) Columns() []string {
+	if r.detail {
+		return []string{"Address", "Kind", "Text"}
+	}
+	return append([]string{"Chapter", "Sentences", "Flagged"}, StyleKinds...)
+}
+
+func (r *StyleReport) Rows() [][]string {
+	rows := make([][]string, 0)
+	for _, c := range r.Chapters {
+		if r.detail {
+			for _, f := range c.Findings {
+				rows = append(rows, []string{f.Address.String(), f.Kind, f.Text})
+			}
+			continue
+		}
+		row := []string{strconv.Itoa(c.Chapter), strconv.Itoa(c.Sentences), strconv.Itoa(c.Flagged)}
+		for _, kind := range StyleKinds {
+			row = append(row, strconv.Itoa(c.Counts[kind]))
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+func (r *StyleReport) String() string {
+	sb := strings.Builder{}
+	for _, c := range r.Chapters {
+		sb.WriteString(fmt.Sprintf("%03d: %d of %d sentences flagged; adverbs %d, filter words %d, weak verbs %d, passives %d\n",
+			c.Chapter, c.Flagged, c.Sentences, c.Counts[StyleAdverb], c.Counts[StyleFilter], c.Counts[StyleWeak], c.Counts[StylePassive]))
+		if r.detail {
+			for _, f := range c.Findings {
+				sb.WriteString(fmt.Sprintf("     %-10v %-8v %v\n", f.Address, f.Kind, f.Text))
+			}
+		}
+	}
+	return sb.String()
+}
+
+// StyleAnalyzer totals adverbs, filter words, weak verbs and passive
+// constructions per chapter, listing each finding if Detail is set.
+type StyleAnalyzer struct {
+	Detail bool
+}
+
+func (a StyleAnalyzer) Name() string { return "style" }
+func (a StyleAnalyzer) Description() string {
+	return "Counts adverbs, filter words, weak verbs and passive constructions"
+}
+func (a StyleAnalyzer) Unit() int { return Chapter }
+
+func (a StyleAnalyzer) Analyze(c *Chunk) Result {
+	r := &StyleReport{Chapters: make([]ChapterStyle, 0), detail: a.Detail}
+	for i := range c.Chapters() {
+		r.Chapters = append(r.Chapters, ChapterStyle{Chapter: i + 1, Counts: make(map[string]int)})
+	}
+	c.Walk(Sentence, func(addr Address, s *Chunk) bool {
+		cs := &r.Chapters[addr.Chapter-1]
+		cs.Sentences = cs.Sentences + 1
+		findings := StyleFindings(addr, s)
+		if len(findings) > 0 {
+			cs.Flagged = cs.Flagged + 1
+		}
+		for _, f := range findings {
+			cs.Counts[f.Kind] = cs.Counts[f.Kind] + 1
+		}
+		if a.Detail {
+			cs.Findings = append(cs.Findings, findings...)
+		}
+		return true
+	})
+	return r
+}
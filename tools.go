@@ -38,17 +38,5 @@ func SortAndPrintLines(ss []string) {
 }
 
 func PrintNameFrequency(nf map[string]int) {
-	names := make([]string, 0, len(nf))
-	for k, _ := range nf {
-		names = append(names, k)
-	}
-	sort.Slice(names, func(i, j int) bool {
-		if names[i] < names[j] {
-			return true
-		}
-		return false
-	})
-	for _, k := range names {
-		fmt.Printf("%v: %-10d\n", k, nf[k])
-	}
+	fmt.Print(NewFrequencyList(nf).String())
 }
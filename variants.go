@@ -0,0 +1,368 @@
+package booktools
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+func init() {
+	RegisterAnalyzer(VariantAnalyzer{MinLength: 5})
+	RegisterAnalyzer(CharacterAliasAnalyzer{MinLength: 4})
+}
+
+// RegionalVariants are groups of spellings which mean the same thing,
+// of which a manuscript should normally use only one.
+var RegionalVariants = [][]string{
+	{"grey", "gray"},
+	{"toward", "towards"},
+	{"afterward", "afterwards"},
+	{"backward", "backwards"},
+	{"forward", "forwards"},
+	{"okay", "ok"},
+	{"among", "amongst"},
+	{"while", "whilst"},
+	{"learned", "learnt"},
+	{"spelled", "spelt"},
+	{"dreamed", "dreamt"},
+	{"leaped", "leapt"},
+	{"burned", "burnt"},
+	{"color", "colour"},
+	{"favorite", "favourite"},
+	{"honor", "honour"},
+	{"neighbor", "neighbour"},
+	{"center", "centre"},
+	{"theater", "theatre"},
+	{"traveled", "travelled"},
+	{"traveling", "travelling"},
+	{"canceled", "cancelled"},
+	{"defense", "defence"},
+	{"realize", "realise"},
+	{"realized", "realised"},
+	{"apologize", "apologise"},
+	{"mom", "mum"},
+}
+
+// Variant kinds
+const (
+	VariantRegional    = "regional"
+	VariantHyphenation = "hyphenation"
+	VariantSpelling    = "spelling"
+	VariantName        = "name"
+)
+
+// VariantForm is one spelling of a word, how often it is used and where
+// it is first used.
+type VariantForm struct {
+	Form  string  `json:"form" yaml:"form"`
+	Count int     `json:"count" yaml:"count"`
+	First Address `json:"first" yaml:"first"`
+
+	name bool
+}
+
+// VariantCluster is a group of spellings of what is probably the same
+// word, ordered by how often each is used.
+type VariantCluster struct {
+	Kind  string        `json:"kind" yaml:"kind"`
+	Forms []VariantForm `json:"forms" yaml:"forms"`
+}
+
+// VariantList is the clusters found in a work, in order of first use.
+type VariantList []VariantCluster
+
+func (l VariantList) Columns() []string {
+	return []string{"Cluster", "Kind", "Form", "Count", "First"}
+}
+
+func (l VariantList) Rows() [][]string {
+	rows := make([][]string, 0)
+	for i, c := range l {
+		for _, f := range c.Forms {
+			rows = append(rows, []string{strconv.Itoa(i + 1), c.Kind, f.Form, strconv.Itoa(f.Count), f.First.String()})
+		}
+	}
+	return rows
+}
+
+func (l VariantList) String() string {
+	sb := strings.Builder{}
+	for _, c := range l {
+		forms := make([]string, len(c.Forms))
+		for i, f := range c.Forms {
+			forms[i] = fmt.Sprintf("%v %d (%v)", f.Form, f.Count, f.First)
+		}
+		sb.WriteString(fmt.Sprintf("%-12v %v\n", c.Kind, strings.Join(forms, ", ")))
+	}
+	return sb.String()
+}
+
+// wordInventory counts each distinct word in the work, keyed by WordKey,
+// noting where it is first used and whether it is capitalised away
+// from the start of a sentence.
+func wordInventory(root *Chunk) map[string]*VariantForm {
+	inventory := make(map[string]*VariantForm)
+	var last Address
+	root.Walk(Word, func(a Address, w *Chunk) bool {
+		first := a != last
+		last = a
+		form := TrimPunctuation(NormalizeWord(strings.Replace(w.Word, "’", "'", -1)))
+		k := strings.ToLower(form)
+		if k == "" {
+			return true
+		}
+		f, ok := inventory[k]
+		if !ok {
+			f = &VariantForm{Form: form, First: a}
+			inventory[k] = f
+		}
+		f.Count = f.Count + 1
+		r, _ := utf8.DecodeRuneInString(form)
+		if !first && unicode.IsUpper(r) {
+			f.name = true
+		}
+		return true
+	})
+	return inventory
+}
+
+// clusters joins the forms in the inventory into groups, keeping track
+// of why each group was formed.
+type clusters struct {
+	parent map[string]string
+	kind   map[string]string
+}
+
+func (c *clusters) find(k string) string {
+	for c.parent[k] != "" && c.parent[k] != k {
+		k = c.parent[k]
+	}
+	return k
+}
+
+func (c *clusters) union(a, b, kind string) {
+	ra, rb := c.find(a), c.find(b)
+	if ra == rb {
+		return
+	}
+	c.parent[ra] = ra
+	c.parent[rb] = ra
+	switch {
+	case c.kind[ra] != "":
+	case c.kind[rb] != "":
+		c.kind[ra] = c.kind[rb]
+	default:
+		c.kind[ra] = kind
+	}
+}
+
+// FindVariants clusters the words of a work which are probably different
+// spellings of the same thing: known regional pairs, words written with
+// and without hyphens, and uncommon words or names of at least
+// minLength letters which differ by a single edit.
+func FindVariants(root *Chunk, minLength int) VariantList {
+	inventory := wordInventory(root)
+	c := &clusters{parent: make(map[string]string), kind: make(map[string]string)}
+
+	for _, group := range RegionalVariants {
+		for _, w := range group[1:] {
+			if inventory[group[0]] != nil && inventory[w] != nil {
+				c.union(group[0], w, VariantRegional)
+			}
+		}
+	}
+
+	unhyphenated := make(map[string]string)
+	for k := range inventory {
+		if !strings.Contains(k, "-") {
+			unhyphenated[k] = k
+		}
+	}
+	for k := range inventory {
+		if joined := strings.Replace(k, "-", "", -1); joined != k {
+			if other, ok := unhyphenated[joined]; ok {
+				c.union(other, k, VariantHyphenation)
+			} else {
+				unhyphenated[joined] = k
+			}
+		}
+	}
+
+	// Words within one edit of each other share a form with one letter
+	// deleted, so only those sharing one need be compared.
+	common := EnglishBaseline().counts
+	deletions := make(map[string][]string)
+	for k, f := range inventory {
+		if utf8.RuneCountInString(k) < minLength || (!f.name && common[k] > 0) {
+			continue
+		}
+		runes := []rune(k)
+		deletions[k] = append(deletions[k], k)
+		for i := range runes {
+			d := string(runes[:i]) + string(runes[i+1:])
+			deletions[d] = append(deletions[d], k)
+		}
+	}
+	for _, keys := range deletions {
+		for i, a := range keys {
+			for _, b := range keys[i+1:] {
+				fa, fb := inventory[a], inventory[b]
+				if a == b || fa.name != fb.name || Stem(a) == Stem(b) || editDistance(a, b) != 1 {
+					continue
+				}
+				kind := VariantSpelling
+				if fa.name {
+					kind = VariantName
+				}
+				c.union(a, b, kind)
+			}
+		}
+	}
+
+	groups := make(map[string][]VariantForm)
+	for k := range c.parent {
+		r := c.find(k)
+		groups[r] = append(groups[r], *inventory[k])
+	}
+	l := make(VariantList, 0, len(groups))
+	for r, forms := range groups {
+		sort.Slice(forms, func(i, j int) bool {
+			if forms[i].Count != forms[j].Count {
+				return forms[i].Count > forms[j].Count
+			}
+			return forms[i].First.Before(forms[j].First)
+		})
+		l = append(l, VariantCluster{Kind: c.kind[r], Forms: forms})
+	}
+	sort.Slice(l, func(i, j int) bool {
+		if l[i].first() != l[j].first() {
+			return l[i].first().Before(l[j].first())
+		}
+		return l[i].Forms[0].Form < l[j].Forms[0].Form
+	})
+	return l
+}
+
+func (c VariantCluster) first() Address {
+	a := c.Forms[0].First
+	for _, f := range c.Forms[1:] {
+		if f.First.Before(a) {
+			a = f.First
+		}
+	}
+	return a
+}
+
+// editDistance is the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	x, y := []rune(a), []rune(b)
+	prev := make([]int, len(y)+1)
+	cur := make([]int, len(y)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(x); i++ {
+		cur[0] = i
+		for j := 1; j <= len(y); j++ {
+			cost := 1
+			if x[i-1] == y[j-1] {
+				cost = 0
+			}
+			cur[j] = min3(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(y)]
+}
+
+func min3(a, b, c int) int {
+	if b < a {
+		a = b
+	}
+	if c < a {
+		a = c
+	}
+	return a
+}
+
+// VariantAnalyzer reports words spelled more than one way.
+type VariantAnalyzer struct {
+	MinLength int
+}
+
+func (a VariantAnalyzer) Name() string { return "variants" }
+func (a VariantAnalyzer) Description() string {
+	return "Finds words and names spelled more than one way"
+}
+func (a VariantAnalyzer) Unit() int { return Work }
+
+func (a VariantAnalyzer) Analyze(c *Chunk) Result {
+	return FindVariants(c, a.MinLength)
+}
+
+// CharacterAlias suggests that the Aliases are other spellings of the
+// character Name.
+type CharacterAlias struct {
+	Name    string   `json:"name" yaml:"name"`
+	Aliases []string `json:"aliases" yaml:"aliases"`
+}
+
+// AliasList is the suggested aliases for each character, sorted by name.
+type AliasList []CharacterAlias
+
+func (l AliasList) Columns() []string { return []string{"Character", "Aliases"} }
+
+func (l AliasList) Rows() [][]string {
+	rows := make([][]string, len(l))
+	for i, a := range l {
+		rows[i] = []string{a.Name, strings.Join(a.Aliases, ",")}
+	}
+	return rows
+}
+
+func (l AliasList) String() string {
+	sb := strings.Builder{}
+	for _, a := range l {
+		sb.WriteString(fmt.Sprintf("%v: %v\n", a.Name, strings.Join(a.Aliases, ", ")))
+	}
+	return sb.String()
+}
+
+// SuggestAliases takes the clusters of names found by FindVariants and
+// suggests the less used spellings as aliases of the most used.
+func SuggestAliases(root *Chunk, minLength int) AliasList {
+	l := make(AliasList, 0)
+	for _, c := range FindVariants(root, minLength) {
+		if c.Kind != VariantName {
+			continue
+		}
+		a := CharacterAlias{Name: c.Forms[0].Form}
+		for _, f := range c.Forms[1:] {
+			a.Aliases = append(a.Aliases, f.Form)
+		}
+		l = append(l, a)
+	}
+	sort.Slice(l, func(i, j int) bool {
+		return l[i].Name < l[j].Name
+	})
+	return l
+}
+
+// CharacterAliasAnalyzer suggests other spellings of the characters'
+// names, as found by SuggestAliases.
+type CharacterAliasAnalyzer struct {
+	MinLength int
+}
+
+func (a CharacterAliasAnalyzer) Name() string { return "characterAliases" }
+func (a CharacterAliasAnalyzer) Description() string {
+	return "Suggests other spellings of the characters' names"
+}
+func (a CharacterAliasAnalyzer) Unit() int { return Work }
+
+func (a CharacterAliasAnalyzer) Analyze(c *Chunk) Result {
+	return SuggestAliases(c, a.MinLength)
+}
@@ -0,0 +1,142 @@
+package booktools
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Watcher polls files for changes to their size or modification time,
+// which works the same on every platform and through editors which
+// save by replacing the file.
+type Watcher struct {
+	Paths    []string
+	Interval time.Duration
+
+	stamps map[string]fileStamp
+}
+
+type fileStamp struct {
+	size    int64
+	modTime time.Time
+}
+
+// NewWatcher watches paths, checking every interval.
+func NewWatcher(interval time.Duration, paths ...string) *Watcher {
+	w := &Watcher{Paths: paths, Interval: interval}
+	w.Changed()
+	return w
+}
+
+// Changed reports whether any of the files has changed since it was
+// last called, or since the Watcher was made.
+func (w *Watcher) Changed() bool {
+	stamps := make(map[string]fileStamp, len(w.Paths))
+	for _, p := range w.Paths {
+		info, err := os.Stat(p)
+		if err != nil {
+			// A file being saved may briefly not exist; it counts as
+			// unchanged until it is back.
+			if old, ok := w.stamps[p]; ok {
+				stamps[p] = old
+			}
+			continue
+		}
+		stamps[p] = fileStamp{size: info.Size(), modTime: info.ModTime()}
+	}
+	changed := false
+	for p, s := range stamps {
+		if old, ok := w.stamps[p]; ok && old != s {
+			changed = true
+		}
+	}
+	w.stamps = stamps
+	return changed
+}
+
+// Wait blocks until one of the files changes, or stop is closed, and
+// reports whether it was a change.
+func (w *Watcher) Wait(stop <-chan struct{}) bool {
+	interval := w.Interval
+	if interval <= 0 {
+		interval = time.Second
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return false
+		case <-ticker.C:
+			if w.Changed() {
+				return true
+			}
+		}
+	}
+}
+
+// Delta is how a work changed between two parses.
+type Delta struct {
+	Words          int      `json:"words" yaml:"words"`
+	Chapters       int      `json:"chapters" yaml:"chapters"`
+	Sections       int      `json:"sections" yaml:"sections"`
+	NewCharacters  []string `json:"newCharacters,omitempty" yaml:"newCharacters,omitempty"`
+	LostCharacters []string `json:"lostCharacters,omitempty" yaml:"lostCharacters,omitempty"`
+}
+
+// Compare returns the change from the work old to the work new.
+func Compare(old *Chunk, new *Chunk) Delta {
+	d := Delta{
+		Words:    new.GetWordCount() - old.GetWordCount(),
+		Chapters: len(new.Chapters()) - len(old.Chapters()),
+		Sections: len(new.Find(Section)) - len(old.Find(Section)),
+	}
+	oldNames := CharacterFrequencies(old, 3, 1)
+	newNames := CharacterFrequencies(new, 3, 1)
+	for name := range newNames {
+		if _, ok := oldNames[name]; !ok {
+			d.NewCharacters = append(d.NewCharacters, name)
+		}
+	}
+	for name := range oldNames {
+		if _, ok := newNames[name]; !ok {
+			d.LostCharacters = append(d.LostCharacters, name)
+		}
+	}
+	sort.Strings(d.NewCharacters)
+	sort.Strings(d.LostCharacters)
+	return d
+}
+
+// String summarises the delta, as in "+412 words, new character: Idris".
+func (d Delta) String() string {
+	parts := make([]string, 0)
+	counts := []struct {
+		n    int
+		unit string
+	}{{d.Words, "word"}, {d.Chapters, "chapter"}, {d.Sections, "section"}}
+	for _, c := range counts {
+		if c.n != 0 {
+			parts = append(parts, fmt.Sprintf("%+d %v", c.n, plural(c.n, c.unit)))
+		}
+	}
+	if len(d.NewCharacters) > 0 {
+		parts = append(parts, fmt.Sprintf("new %v: %v", plural(len(d.NewCharacters), "character"), strings.Join(d.NewCharacters, ", ")))
+	}
+	if len(d.LostCharacters) > 0 {
+		parts = append(parts, fmt.Sprintf("lost %v: %v", plural(len(d.LostCharacters), "character"), strings.Join(d.LostCharacters, ", ")))
+	}
+	if len(parts) == 0 {
+		return "no change in counts"
+	}
+	return strings.Join(parts, ", ")
+}
+
+func plural(n int, unit string) string {
+	if n == 1 || n == -1 {
+		return unit
+	}
+	return unit + "s"
+}